@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/smartystreets/projector/diff"
+)
+
+func runDiff(arguments []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	prefix := flags.String("prefix", "/", "compare the documents whose paths begin with the prefix")
+	quiet := flags.Bool("quiet", false, "report only the summary")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: projector diff [flags] <left storage> <right storage> [paths...]")
+		fmt.Fprintln(flags.Output(), "\nCompares the listed documents (or those at the paths given), exiting with 1 when any differ.")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(arguments)
+	if flags.NArg() < 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	left, err := openStorage(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	right, err := openStorage(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	comparer := diff.New(left, right)
+	visit := func(report diff.Report) {
+		if !*quiet {
+			printReport(os.Stdout, report)
+		}
+	}
+
+	var summary diff.Summary
+	if paths := flags.Args()[2:]; len(paths) > 0 {
+		for _, path := range paths {
+			report, err := comparer.Compare(path)
+			if err != nil {
+				return err
+			}
+			visit(report)
+			summary.Add(report)
+		}
+	} else if summary, err = comparer.CompareAll(*prefix, visit); err != nil {
+		return err
+	}
+
+	fmt.Printf("%d documents compared: %d identical, %d different (%d only left, %d only right); fields: %d added, %d removed, %d changed\n",
+		summary.Documents, summary.Identical, summary.Different, summary.OnlyLeft, summary.OnlyRight,
+		summary.Added, summary.Removed, summary.Changed)
+
+	if summary.Different > 0 {
+		return errDifferent
+	}
+	return nil
+}
+
+func printReport(writer io.Writer, report diff.Report) {
+	switch {
+	case report.Identical():
+		return
+	case report.OnlyLeft:
+		fmt.Fprintf(writer, "- %s (only left)\n", report.Path)
+		return
+	case report.OnlyRight:
+		fmt.Fprintf(writer, "+ %s (only right)\n", report.Path)
+		return
+	}
+
+	fmt.Fprintf(writer, "~ %s\n", report.Path)
+	for _, change := range report.Changes {
+		fmt.Fprintf(writer, "    %-7s %s: %s -> %s\n", change.Kind, change.Field, describe(change.Left), describe(change.Right))
+	}
+}
+func describe(value interface{}) string {
+	if value == nil {
+		return "(none)"
+	}
+	raw, _ := json.Marshal(value)
+	return string(raw)
+}
+
+var errDifferent = errors.New("the documents differ")
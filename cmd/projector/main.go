@@ -0,0 +1,52 @@
+// Command projector maintains projected documents in storage addressed as "gs://bucket/prefix" or
+// "https://bucket.s3-us-west-1.amazonaws.com/prefix", with credentials from the conventional environment variables.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+)
+
+type command struct {
+	summary string
+	run     func(arguments []string) error
+}
+
+var commands = map[string]command{
+	"diff":      {summary: "compare the documents of two storages", run: runDiff},
+	"migrate":   {summary: "copy the documents of one storage to another", run: runMigrate},
+	"retention": {summary: "archive or delete the expired documents of a storage", run: runRetention},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	command, found := commands[os.Args[1]]
+	if !found {
+		usage()
+	}
+
+	if err := command.run(os.Args[2:]); err == errDifferent {
+		os.Exit(1)
+	} else if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func usage() {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "Usage: projector <command> [arguments]\n\nCommands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].summary)
+	}
+	os.Exit(2)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/smartystreets/projector/migrate"
+)
+
+func runMigrate(arguments []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	prefix := flags.String("prefix", "/", "migrate the documents whose paths begin with the prefix")
+	workers := flags.Int("workers", 8, "the number of documents to migrate at once")
+	stateFile := flags.String("state", "", "the file recording the documents migrated, which allows an interrupted migration to resume")
+	verify := flags.Bool("verify", true, "read each document back from the destination to verify its checksum")
+	verbatim := flags.Bool("verbatim", true, "copy each document as stored, keeping its compression and encryption")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: projector migrate [flags] <source storage> <destination storage>")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(arguments)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	source, err := openStorage(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	lister, ok := source.(migrate.Source)
+	if !ok {
+		return errors.New("the source storage is unable to list its documents")
+	}
+	destination, err := openStorage(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	options := []migrate.Option{
+		migrate.Prefix(*prefix), migrate.Workers(*workers), migrate.Verify(*verify), migrate.Verbatim(*verbatim),
+	}
+	if len(*stateFile) > 0 {
+		state, err := migrate.OpenState(*stateFile)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = state.Close() }()
+		options = append(options, migrate.Resume(state))
+	}
+
+	report, err := migrate.New(lister, destination, options...).Run()
+	fmt.Printf("%d documents listed: %d copied, %d skipped (already migrated or since removed), %d failed\n",
+		report.Listed, report.Copied, report.Skipped, report.Failed)
+	if err != nil {
+		return err
+	} else if report.Failed > 0 {
+		return errIncomplete
+	}
+	return nil
+}
+
+var errIncomplete = errors.New("some documents failed to migrate; run the migration again to resume")
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/smartystreets/projector/retention"
+)
+
+func runRetention(arguments []string) error {
+	flags := flag.NewFlagSet("retention", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "report the documents which would be archived or deleted without changing any")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: projector retention [flags] <storage> <rules file>")
+		fmt.Fprintln(flags.Output(), "\nApplies the retention rules (see retention.LoadRules) to the documents of the storage once.")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(arguments)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	storage, err := openStorage(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	managed, ok := storage.(retention.Storage)
+	if !ok {
+		return errors.New("the storage is unable to list and delete its documents")
+	}
+	rules, err := retention.LoadRules(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	report, err := retention.NewManager(time.Now, managed, rules...).WithDryRun(*dryRun).Run()
+	fmt.Printf("%d documents examined: %d retained, %d archived, %d deleted, %d failed\n",
+		report.Examined, report.Retained, report.Archived, report.Deleted, report.Failed)
+	if err != nil {
+		return err
+	} else if report.Failed > 0 {
+		return errRetentionIncomplete
+	}
+	return nil
+}
+
+var errRetentionIncomplete = errors.New("some expired documents couldn't be archived or deleted; run the rules again to retry")
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/smartystreets/projector/persist"
+	"github.com/smartystreets/projector/persist/anypersist"
+	"github.com/smartystreets/projector/persist/credentials"
+)
+
+// openStorage builds the storage at the address, which names its engine, bucket, and any path prefix.
+func openStorage(address string) (persist.ReadWriter, error) {
+	parsed, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("malformed storage address '%s': %s", address, err)
+	}
+
+	// the credentials are resolved again for each request, which lets them be rotated during a long migration
+	provider := credentials.Environment()
+	if _, err = provider.Credentials(); err != nil {
+		return nil, fmt.Errorf("unable to resolve credentials from the environment: %s", err)
+	}
+
+	switch parsed.Scheme {
+	case "gs":
+		return anypersist.New(
+			anypersist.GoogleCloudStorage(context.Background(), parsed.Host, strings.Trim(parsed.Path, "/"), nil),
+			anypersist.CredentialsFrom(provider),
+			anypersist.MaxRetries(maxRetries)).Build()
+
+	case "http", "https":
+		return anypersist.New(
+			anypersist.S3(parsed, "", ""),
+			anypersist.CredentialsFrom(provider),
+			anypersist.MaxRetries(maxRetries)).Build()
+
+	default:
+		return nil, fmt.Errorf("unsupported storage address '%s' (expected gs:// or https://)", address)
+	}
+}
+
+const maxRetries = 3
@@ -0,0 +1,133 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Kind string
+
+const (
+	Added   Kind = "added"
+	Removed Kind = "removed"
+	Changed Kind = "changed"
+)
+
+// Change describes one difference between two JSON documents. The field is given as a
+// JSON Pointer (e.g. "/totals/0/count"); Left and Right hold the decoded values, either
+// of which is nil for fields which were added or removed.
+type Change struct {
+	Field string
+	Kind  Kind
+	Left  interface{}
+	Right interface{}
+}
+
+// Changes compares two JSON documents structurally, disregarding formatting and the order of fields,
+// and gives the changes in order of their fields. Empty JSON is regarded as a missing document.
+func Changes(left, right json.RawMessage) ([]Change, error) {
+	leftValue, err := decode(left)
+	if err != nil {
+		return nil, err
+	}
+
+	rightValue, err := decode(right)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	if missing(left) && !missing(right) {
+		changes = append(changes, Change{Field: "/", Kind: Added, Right: rightValue})
+	} else if !missing(left) && missing(right) {
+		changes = append(changes, Change{Field: "/", Kind: Removed, Left: leftValue})
+	} else {
+		compare("", leftValue, rightValue, &changes)
+	}
+	return changes, nil
+}
+func missing(raw json.RawMessage) bool {
+	return len(bytes.TrimSpace(raw)) == 0
+}
+func decode(raw json.RawMessage) (value interface{}, err error) {
+	if missing(raw) {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber() // compare numbers as written, without loss of precision
+	err = decoder.Decode(&value)
+	return value, err
+}
+
+func compare(field string, left, right interface{}, changes *[]Change) {
+	leftObject, leftIsObject := left.(map[string]interface{})
+	rightObject, rightIsObject := right.(map[string]interface{})
+	if leftIsObject && rightIsObject {
+		compareObjects(field, leftObject, rightObject, changes)
+		return
+	}
+
+	leftArray, leftIsArray := left.([]interface{})
+	rightArray, rightIsArray := right.([]interface{})
+	if leftIsArray && rightIsArray {
+		compareArrays(field, leftArray, rightArray, changes)
+		return
+	}
+
+	if !reflect.DeepEqual(left, right) {
+		*changes = append(*changes, Change{Field: pointer(field), Kind: Changed, Left: left, Right: right})
+	}
+}
+func compareObjects(field string, left, right map[string]interface{}, changes *[]Change) {
+	var names []string
+	for name := range left {
+		names = append(names, name)
+	}
+	for name := range right {
+		if _, found := left[name]; !found {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		nested := field + "/" + escape(name)
+		leftValue, onLeft := left[name]
+		rightValue, onRight := right[name]
+
+		if !onLeft {
+			*changes = append(*changes, Change{Field: nested, Kind: Added, Right: rightValue})
+		} else if !onRight {
+			*changes = append(*changes, Change{Field: nested, Kind: Removed, Left: leftValue})
+		} else {
+			compare(nested, leftValue, rightValue, changes)
+		}
+	}
+}
+func compareArrays(field string, left, right []interface{}, changes *[]Change) {
+	for i := 0; i < len(left) || i < len(right); i++ {
+		nested := field + "/" + strconv.Itoa(i)
+		if i >= len(left) {
+			*changes = append(*changes, Change{Field: nested, Kind: Added, Right: right[i]})
+		} else if i >= len(right) {
+			*changes = append(*changes, Change{Field: nested, Kind: Removed, Left: left[i]})
+		} else {
+			compare(nested, left[i], right[i], changes)
+		}
+	}
+}
+
+func pointer(field string) string {
+	if len(field) == 0 {
+		return "/"
+	}
+	return field
+}
+func escape(name string) string {
+	return strings.Replace(strings.Replace(name, "~", "~0", -1), "/", "~1", -1)
+}
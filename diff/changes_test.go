@@ -0,0 +1,59 @@
+package diff
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestChangesFixture(t *testing.T) {
+	gunit.Run(new(ChangesFixture), t)
+}
+
+type ChangesFixture struct {
+	*gunit.Fixture
+}
+
+func (this *ChangesFixture) TestEquivalentDocumentsHaveNoChanges() {
+	changes, err := Changes(json.RawMessage(`{"a": 1, "b": [1, 2]}`), json.RawMessage(`{"b":[1,2],"a":1}`))
+
+	this.So(err, should.BeNil)
+	this.So(changes, should.BeEmpty)
+}
+
+func (this *ChangesFixture) TestFieldsAddedRemovedAndChanged() {
+	changes, _ := Changes(
+		json.RawMessage(`{"kept": 1, "removed": true, "nested": {"count": 1, "a/b": "x"}, "list": [1, 2, 3]}`),
+		json.RawMessage(`{"kept": 1, "added": "yes", "nested": {"count": 2, "a/b": "y"}, "list": [1, 4]}`))
+
+	this.So(changes, should.Resemble, []Change{
+		{Field: "/added", Kind: Added, Right: "yes"},
+		{Field: "/list/1", Kind: Changed, Left: json.Number("2"), Right: json.Number("4")},
+		{Field: "/list/2", Kind: Removed, Left: json.Number("3")},
+		{Field: "/nested/a~1b", Kind: Changed, Left: "x", Right: "y"},
+		{Field: "/nested/count", Kind: Changed, Left: json.Number("1"), Right: json.Number("2")},
+		{Field: "/removed", Kind: Removed, Left: true},
+	})
+}
+
+func (this *ChangesFixture) TestChangedTypeIsChange() {
+	changes, _ := Changes(json.RawMessage(`{"a": {"b": 1}}`), json.RawMessage(`{"a": [1]}`))
+
+	this.So(changes, should.Resemble, []Change{
+		{Field: "/a", Kind: Changed, Left: map[string]interface{}{"b": json.Number("1")}, Right: []interface{}{json.Number("1")}},
+	})
+}
+
+func (this *ChangesFixture) TestMissingDocumentAddsWholeDocument() {
+	changes, _ := Changes(nil, json.RawMessage(`{"a": 1}`))
+
+	this.So(changes, should.Resemble, []Change{{Field: "/", Kind: Added, Right: map[string]interface{}{"a": json.Number("1")}}})
+}
+
+func (this *ChangesFixture) TestMalformedJSONRejected() {
+	_, err := Changes(json.RawMessage(`{`), nil)
+
+	this.So(err, should.NotBeNil)
+}
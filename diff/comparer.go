@@ -0,0 +1,158 @@
+package diff
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/smartystreets/projector/persist"
+)
+
+// Report describes how a document differs between the left and right storage.
+type Report struct {
+	Path      string
+	Changes   []Change
+	OnlyLeft  bool
+	OnlyRight bool
+}
+
+func (this Report) Identical() bool {
+	return len(this.Changes) == 0
+}
+
+// Summary tallies the documents compared and the changes found among them.
+type Summary struct {
+	Documents int
+	Identical int
+	Different int
+	OnlyLeft  int
+	OnlyRight int
+	Added     int
+	Removed   int
+	Changed   int
+}
+
+// Add tallies the report.
+func (this *Summary) Add(report Report) {
+	this.Documents++
+	if report.Identical() {
+		this.Identical++
+	} else {
+		this.Different++
+	}
+	if report.OnlyLeft {
+		this.OnlyLeft++
+	}
+	if report.OnlyRight {
+		this.OnlyRight++
+	}
+
+	for _, change := range report.Changes {
+		switch change.Kind {
+		case Added:
+			this.Added++
+		case Removed:
+			this.Removed++
+		case Changed:
+			this.Changed++
+		}
+	}
+}
+
+// Comparer compares the documents at the same paths in two storages, such as a projection
+// and its rebuild, or a backend and the one to which it was migrated.
+type Comparer struct {
+	left  persist.Reader
+	right persist.Reader
+}
+
+func New(left, right persist.Reader) *Comparer {
+	return &Comparer{left: left, right: right}
+}
+
+// Compare reads the document at the path from each storage and compares them.
+func (this *Comparer) Compare(path string) (Report, error) {
+	return this.compare(persist.DocumentInfo{Path: path})
+}
+
+// compare reads the document as listed, which locates it by its type and date
+// as well as its path where the key of either storage depends upon them.
+func (this *Comparer) compare(info persist.DocumentInfo) (Report, error) {
+	path := info.Path
+	left, right := persist.NewListedDocument(info), persist.NewListedDocument(info)
+	left.Reset()
+	right.Reset()
+	if err := this.left.Read(left); err != nil {
+		return Report{}, err
+	} else if err = this.right.Read(right); err != nil {
+		return Report{}, err
+	}
+
+	changes, err := Changes(left.Body(), right.Body())
+	if err != nil {
+		return Report{}, err
+	}
+
+	return Report{
+		Path:      path,
+		Changes:   changes,
+		OnlyLeft:  len(left.Body()) > 0 && len(right.Body()) == 0,
+		OnlyRight: len(left.Body()) == 0 && len(right.Body()) > 0,
+	}, nil
+}
+
+// CompareAll compares every document whose path begins with the prefix in either storage, in order of their paths,
+// passing each report to visit and giving back the summary. Storage which can't be listed is only read.
+func (this *Comparer) CompareAll(prefix string, visit func(Report)) (summary Summary, err error) {
+	listed, err := this.list(prefix)
+	if err != nil {
+		return summary, err
+	}
+
+	for _, info := range listed {
+		report, err := this.compare(info)
+		if err != nil {
+			return summary, err
+		}
+
+		summary.Add(report)
+		if visit != nil {
+			visit(report)
+		}
+	}
+
+	return summary, nil
+}
+func (this *Comparer) list(prefix string) ([]persist.DocumentInfo, error) {
+	unique := map[string]persist.DocumentInfo{}
+	listed := false
+
+	for _, reader := range []persist.Reader{this.left, this.right} {
+		lister, ok := reader.(persist.Lister)
+		if !ok {
+			continue
+		}
+
+		listed = true
+		if err := lister.List(prefix, func(info persist.DocumentInfo) error {
+			if _, contains := unique[info.Path]; !contains {
+				unique[info.Path] = info
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if !listed {
+		return nil, ErrUnlistable
+	}
+
+	infos := make([]persist.DocumentInfo, 0, len(unique))
+	for _, info := range unique {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Path < infos[j].Path })
+	return infos, nil
+}
+
+var ErrUnlistable = errors.New("neither storage is able to list its documents")
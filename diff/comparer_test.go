@@ -0,0 +1,87 @@
+package diff
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector/persist"
+	"github.com/smartystreets/projector/persist/persisttest"
+)
+
+func TestComparerFixture(t *testing.T) {
+	gunit.Run(new(ComparerFixture), t)
+}
+
+type ComparerFixture struct {
+	*gunit.Fixture
+
+	left     *persisttest.Storage
+	right    *persisttest.Storage
+	comparer *Comparer
+}
+
+func (this *ComparerFixture) Setup() {
+	this.left = persisttest.NewStorage("left")
+	this.left.Bodies = map[string]string{
+		"/same.json":      `{"a": 1}`,
+		"/different.json": `{"a": 1, "b": 2}`,
+		"/left.json":      `{}`,
+		"/other/x.json":   `{}`,
+	}
+	this.right = persisttest.NewStorage("right")
+	this.right.Bodies = map[string]string{
+		"/same.json":      `{"a":1}`,
+		"/different.json": `{"a": 2, "c": 3}`,
+		"/right.json":     `{}`,
+	}
+	this.comparer = New(this.left, this.right)
+}
+
+func (this *ComparerFixture) TestCompareDocumentAtPath() {
+	report, err := this.comparer.Compare("/different.json")
+
+	this.So(err, should.BeNil)
+	this.So(report.Path, should.Equal, "/different.json")
+	this.So(report.Identical(), should.BeFalse)
+	this.So(report.Changes, should.HaveLength, 3)
+}
+
+func (this *ComparerFixture) TestCompareAllListingsOfBothStorages() {
+	var visited []string
+
+	summary, err := this.comparer.CompareAll("/", func(report Report) { visited = append(visited, report.Path) })
+
+	this.So(err, should.BeNil)
+	this.So(visited, should.Resemble, []string{"/different.json", "/left.json", "/other/x.json", "/right.json", "/same.json"})
+	this.So(summary, should.Resemble, Summary{
+		Documents: 5, Identical: 1, Different: 4, OnlyLeft: 2, OnlyRight: 1, Added: 2, Removed: 3, Changed: 1,
+	})
+}
+
+func (this *ComparerFixture) TestCompareAllBeneathPrefix() {
+	summary, _ := this.comparer.CompareAll("/other/", nil)
+
+	this.So(summary.Documents, should.Equal, 1)
+}
+
+func (this *ComparerFixture) TestReadFailureGivenBack() {
+	this.right.ReadError = errors.New("BOINK!")
+
+	_, err := this.comparer.CompareAll("/", nil)
+
+	this.So(err, should.Equal, this.right.ReadError)
+}
+
+func (this *ComparerFixture) TestUnlistableStorageRejected() {
+	comparer := New(UnlistableStorage{this.left}, UnlistableStorage{this.right})
+
+	_, err := comparer.CompareAll("/", nil)
+
+	this.So(err, should.Equal, ErrUnlistable)
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+type UnlistableStorage struct{ persist.Reader }
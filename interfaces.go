@@ -17,3 +17,89 @@ type VersionInfo struct{ value interface{} }
 func (this *VersionInfo) SetVersion(value interface{}) { this.value = value }
 func (this *VersionInfo) Version() interface{}         { return this.value }
 func (this *VersionInfo) Reset()                       { this.value = nil }
+
+// Removable documents may ask, from either Apply or Lapse, to be deleted from storage rather than written.
+// Once deleted the document is Reset, which should also withdraw the request for removal.
+type Removable interface {
+	Removed() bool
+}
+
+type RemovalInfo struct{ removed bool }
+
+func (this *RemovalInfo) Remove()       { this.removed = true }
+func (this *RemovalInfo) Removed() bool { return this.removed }
+func (this *RemovalInfo) Reset()        { this.removed = false }
+
+// Mergeable documents, such as counters or sums, keep the changes applied since they were last saved, and merge them
+// into the document written by another process upon a conflict rather than apply the messages again.
+type Mergeable interface {
+	// Merge sets the document to the state of the remote document (of the same type), as written by another
+	// process, together with the changes applied locally since the document was last saved.
+	Merge(remote Document)
+
+	// Saved is called once the document has been written, after which it has no changes of its own.
+	Saved()
+}
+
+// Checkpointed documents persist the identifier (MessageID) of the last delivery applied to them, which is
+// assumed to increase with each delivery, such that redelivered messages at or below it are skipped.
+type Checkpointed interface {
+	Checkpoint() uint64
+	SetCheckpoint(uint64)
+}
+
+type CheckpointInfo struct {
+	LastMessageID uint64 `json:"last_message_id,omitempty"`
+}
+
+func (this *CheckpointInfo) Checkpoint() uint64         { return this.LastMessageID }
+func (this *CheckpointInfo) SetCheckpoint(value uint64) { this.LastMessageID = value }
+func (this *CheckpointInfo) Reset()                     { this.LastMessageID = 0 }
+
+// Deduplicated documents persist the identities of the messages most recently applied to them,
+// such that messages redelivered, perhaps out of order, are recognized and skipped.
+type Deduplicated interface {
+	Seen(id string) bool
+	MarkSeen(id string, capacity int)
+}
+
+// DeduplicationInfo remembers the identities in the order they were seen, indexing them as they're first
+// consulted. The index is discarded by Reset, which must precede reading the document again.
+type DeduplicationInfo struct {
+	SeenMessages []string `json:"seen_messages,omitempty"`
+
+	index map[string]struct{}
+}
+
+func (this *DeduplicationInfo) Seen(id string) bool {
+	if this.index == nil || len(this.index) != len(this.SeenMessages) {
+		this.index = make(map[string]struct{}, len(this.SeenMessages))
+		for _, seen := range this.SeenMessages {
+			this.index[seen] = struct{}{}
+		}
+	}
+
+	_, seen := this.index[id]
+	return seen
+}
+
+// MarkSeen remembers the identity, forgetting the oldest of those remembered beyond the capacity (at least one).
+func (this *DeduplicationInfo) MarkSeen(id string, capacity int) {
+	if this.Seen(id) {
+		return
+	}
+
+	this.SeenMessages = append(this.SeenMessages, id)
+	this.index[id] = struct{}{}
+
+	if capacity < 1 {
+		capacity = 1
+	}
+	if excess := len(this.SeenMessages) - capacity; excess > 0 {
+		for _, forgotten := range this.SeenMessages[:excess] {
+			delete(this.index, forgotten)
+		}
+		this.SeenMessages = append(this.SeenMessages[0:0], this.SeenMessages[excess:]...)
+	}
+}
+func (this *DeduplicationInfo) Reset() { this.SeenMessages, this.index = nil, nil }
@@ -0,0 +1,144 @@
+package lease
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/smartystreets/projector/persist"
+)
+
+// Lease is held by no more than one owner at a time, as recorded by a document which is written conditionally
+// and expires unless renewed, such that only one of several instances of a projector is active.
+type Lease struct {
+	storage  persist.ReadWriter
+	path     string
+	owner    string
+	now      func() time.Time
+	duration time.Duration
+	interval time.Duration
+	shutdown chan struct{}
+
+	mutex   sync.Mutex
+	expires time.Time
+}
+
+func New(storage persist.ReadWriter, path, owner string, now func() time.Time) *Lease {
+	return &Lease{
+		storage:  storage,
+		path:     path,
+		owner:    owner,
+		now:      now,
+		duration: time.Second * 30,
+		interval: time.Second * 10,
+		shutdown: make(chan struct{}),
+	}
+}
+
+// WithDuration sets how long the lease is held once acquired or renewed (by default, 30 seconds).
+func (this *Lease) WithDuration(duration time.Duration) *Lease {
+	this.duration = duration
+	return this
+}
+
+// WithInterval sets the time between attempts to acquire or renew the lease when run in the background
+// by Listen (by default, 10 seconds), which should be well within its duration.
+func (this *Lease) WithInterval(interval time.Duration) *Lease {
+	this.interval = interval
+	return this
+}
+
+// Listen acquires the lease, or renews it once held, immediately and then periodically until it is closed,
+// whereupon the lease is released such that another owner may acquire it without waiting for it to expire.
+func (this *Lease) Listen() {
+	for {
+		if _, err := this.Acquire(); err != nil {
+			log.Printf("[WARN] Unable to acquire lease [%s]: %s\n", this.path, err)
+		}
+
+		select {
+		case <-this.shutdown:
+			if err := this.Release(); err != nil {
+				log.Printf("[WARN] Unable to release lease [%s]: %s\n", this.path, err)
+			}
+			return
+		case <-time.After(this.interval):
+		}
+	}
+}
+func (this *Lease) Close() {
+	close(this.shutdown)
+}
+
+// Held reports whether the lease was last acquired or renewed by this owner and has yet to expire.
+func (this *Lease) Held() bool {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	return this.now().Before(this.expires)
+}
+
+// Acquire takes ownership of the lease unless another owner holds it, renewing it when already held,
+// and reports whether it's now held. Losing a race with another owner isn't an error.
+func (this *Lease) Acquire() (bool, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	began := this.now() // the lease is only held locally until it would expire as measured from here
+	record := newRecord(this.path)
+	if err := this.storage.Read(record); err != nil {
+		this.expires = time.Time{}
+		return false, err
+	} else if record.Version() == nil {
+		record.SetVersion(persist.Absent) // should several owners find no record, only one creates it
+	}
+
+	if record.heldBy(this.owner, began) {
+		this.lost(record.Owner)
+		return false, nil
+	}
+
+	record.Owner, record.Expires = this.owner, began.Add(this.duration)
+	if err := this.storage.Write(record); err == persist.ErrConcurrentWrite {
+		this.lost("another owner")
+		return false, nil
+	} else if err != nil {
+		this.expires = time.Time{}
+		return false, err
+	}
+
+	if !began.Before(this.expires) {
+		log.Printf("[INFO] Lease [%s] acquired by [%s] until %s.\n", this.path, this.owner, record.Expires.Format(time.RFC3339))
+	}
+	this.expires = record.Expires
+	return true, nil
+}
+func (this *Lease) lost(owner string) {
+	if this.now().Before(this.expires) {
+		log.Printf("[WARN] Lease [%s] held by [%s] has been taken by [%s].\n", this.path, this.owner, owner)
+	}
+	this.expires = time.Time{}
+}
+
+// Release gives up the lease, if held, by expiring it immediately.
+func (this *Lease) Release() error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	record := newRecord(this.path)
+	if err := this.storage.Read(record); err != nil {
+		return err
+	} else if record.Owner != this.owner {
+		this.expires = time.Time{}
+		return nil
+	}
+
+	record.Owner, record.Expires = "", time.Time{}
+	if err := this.storage.Write(record); err != nil && err != persist.ErrConcurrentWrite {
+		return err
+	}
+
+	this.expires = time.Time{}
+	log.Printf("[INFO] Lease [%s] released by [%s].\n", this.path, this.owner)
+	return nil
+}
@@ -0,0 +1,161 @@
+package lease
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector/persist/persisttest"
+)
+
+func TestLeaseFixture(t *testing.T) {
+	gunit.Run(new(LeaseFixture), t)
+}
+
+type LeaseFixture struct {
+	*gunit.Fixture
+
+	now     time.Time
+	storage *persisttest.Storage
+	first   *Lease
+	second  *Lease
+}
+
+func (this *LeaseFixture) Setup() {
+	this.now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	this.storage = persisttest.NewStorage("fake")
+	this.first = New(this.storage, "/lease.json", "first", this.clock).WithDuration(time.Minute)
+	this.second = New(this.storage, "/lease.json", "second", this.clock).WithDuration(time.Minute)
+}
+func (this *LeaseFixture) clock() time.Time { return this.now }
+
+func (this *LeaseFixture) TestUnheldLeaseAcquired() {
+	acquired, err := this.first.Acquire()
+
+	this.So(err, should.BeNil)
+	this.So(acquired, should.BeTrue)
+	this.So(this.first.Held(), should.BeTrue)
+	this.So(this.storage.Bodies["/lease.json"], should.Equal, `{"owner":"first","expires":"2020-01-01T00:01:00Z"}`)
+}
+
+func (this *LeaseFixture) TestLeaseHeldByAnotherNotAcquired() {
+	_, _ = this.first.Acquire()
+
+	acquired, err := this.second.Acquire()
+
+	this.So(err, should.BeNil)
+	this.So(acquired, should.BeFalse)
+	this.So(this.second.Held(), should.BeFalse)
+	this.So(this.storage.Writes, should.Equal, 1)
+}
+
+func (this *LeaseFixture) TestHeldLeaseRenewed() {
+	_, _ = this.first.Acquire()
+	this.now = this.now.Add(time.Second * 50)
+
+	acquired, _ := this.first.Acquire()
+
+	this.So(acquired, should.BeTrue)
+	this.now = this.now.Add(time.Second * 50)
+	this.So(this.first.Held(), should.BeTrue)
+	this.So(this.storage.Bodies["/lease.json"], should.Equal, `{"owner":"first","expires":"2020-01-01T00:01:50Z"}`)
+}
+
+func (this *LeaseFixture) TestExpiredLeaseTakenOver() {
+	_, _ = this.first.Acquire()
+	this.now = this.now.Add(time.Minute)
+
+	acquired, _ := this.second.Acquire()
+
+	this.So(acquired, should.BeTrue)
+	this.So(this.first.Held(), should.BeFalse)
+	this.So(this.second.Held(), should.BeTrue)
+
+	acquired, _ = this.first.Acquire()
+	this.So(acquired, should.BeFalse)
+}
+
+func (this *LeaseFixture) TestLeaseChangedSinceReadNotAcquired() {
+	this.storage.Put("/lease.json", `{"owner":"second","expires":"2019-12-31T23:59:00Z"}`)
+	this.storage.BeforeWrite = func() { this.storage.Put("/lease.json", `{"owner":"second","expires":"2020-01-01T00:01:00Z"}`) }
+
+	acquired, err := this.first.Acquire()
+
+	this.So(err, should.BeNil)
+	this.So(acquired, should.BeFalse)
+	this.So(this.first.Held(), should.BeFalse)
+}
+
+func (this *LeaseFixture) TestRacingCreationWonByOnlyOne() {
+	this.storage.BeforeWrite = func() {
+		this.storage.BeforeWrite = nil
+		_, _ = this.second.Acquire() // both find no record, and so create it only if it's still absent
+	}
+
+	firstAcquired, err := this.first.Acquire()
+
+	this.So(err, should.BeNil)
+	this.So(firstAcquired, should.BeFalse)
+	this.So(this.first.Held(), should.BeFalse)
+	this.So(this.second.Held(), should.BeTrue)
+	this.So(this.storage.Body("/lease.json"), should.ContainSubstring, `"owner":"second"`)
+}
+
+func (this *LeaseFixture) TestUnreadableLeaseNotHeld() {
+	_, _ = this.first.Acquire()
+	this.storage.ReadError = errors.New("BOINK!")
+
+	acquired, err := this.first.Acquire()
+
+	this.So(err, should.Equal, this.storage.ReadError)
+	this.So(acquired, should.BeFalse)
+	this.So(this.first.Held(), should.BeFalse)
+}
+
+func (this *LeaseFixture) TestLeaseExpiresUnlessRenewed() {
+	_, _ = this.first.Acquire()
+
+	this.now = this.now.Add(time.Minute)
+
+	this.So(this.first.Held(), should.BeFalse)
+}
+
+func (this *LeaseFixture) TestReleasedLeaseAcquiredImmediately() {
+	_, _ = this.first.Acquire()
+
+	err := this.first.Release()
+	acquired, _ := this.second.Acquire()
+
+	this.So(err, should.BeNil)
+	this.So(this.first.Held(), should.BeFalse)
+	this.So(acquired, should.BeTrue)
+}
+
+func (this *LeaseFixture) TestLeaseHeldByAnotherNotReleased() {
+	_, _ = this.first.Acquire()
+
+	err := this.second.Release()
+
+	this.So(err, should.BeNil)
+	this.So(this.first.Held(), should.BeTrue)
+	this.So(this.storage.Writes, should.Equal, 1)
+}
+
+func (this *LeaseFixture) TestListenAcquiresUntilClosedThenReleases() {
+	lease := New(this.storage, "/lease.json", "first", time.Now).WithInterval(time.Millisecond)
+	var waiter sync.WaitGroup
+	waiter.Add(1)
+	go func() { lease.Listen(); waiter.Done() }()
+
+	for !lease.Held() {
+		time.Sleep(time.Millisecond)
+	}
+	lease.Close()
+	waiter.Wait()
+
+	this.So(lease.Held(), should.BeFalse)
+	this.So(this.storage.Body("/lease.json"), should.StartWith, `{"owner":"","expires":"0001-01-01T00:00:00Z"}`)
+}
@@ -0,0 +1,34 @@
+package lease
+
+import (
+	"time"
+
+	"github.com/smartystreets/projector"
+)
+
+// record is the document naming the owner of the lease and when its ownership expires.
+type record struct {
+	projector.VersionInfo
+
+	path string
+
+	Owner   string    `json:"owner"`
+	Expires time.Time `json:"expires"`
+}
+
+func newRecord(path string) *record {
+	return &record{path: path}
+}
+
+func (this *record) Lapse(time.Time) projector.Document { return this }
+func (this *record) Apply(interface{}) bool             { return false }
+func (this *record) Path() string                       { return this.path }
+func (this *record) Reset() {
+	this.Owner, this.Expires = "", time.Time{}
+	this.VersionInfo.Reset()
+}
+
+// heldBy reports whether ownership by another owner than that given has yet to expire.
+func (this *record) heldBy(owner string, now time.Time) bool {
+	return len(this.Owner) > 0 && this.Owner != owner && now.Before(this.Expires)
+}
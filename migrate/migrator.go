@@ -0,0 +1,188 @@
+package migrate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/smartystreets/projector/persist"
+)
+
+// Source is the storage from which documents are migrated.
+type Source interface {
+	persist.Reader
+	persist.Lister
+}
+
+// Report tallies the documents of a migration.
+type Report struct {
+	Listed  int
+	Skipped int
+	Copied  int
+	Failed  int
+}
+
+// Migrator copies every document from the source to the destination storage, as stored where both are able to
+// (see persist.StoredReadWriter), and reads each back from the destination to verify it.
+type Migrator struct {
+	source      Source
+	destination persist.ReadWriter
+	prefix      string
+	workers     int
+	state       *State
+	verify      bool
+	verbatim    bool
+
+	mutex  sync.Mutex
+	report Report
+}
+
+func New(source Source, destination persist.ReadWriter, options ...Option) *Migrator {
+	config := newConfiguration(options)
+	_, readable := source.(persist.StoredReadWriter)
+	_, writable := destination.(persist.StoredReadWriter)
+	return &Migrator{
+		source:      source,
+		destination: destination,
+		prefix:      config.prefix,
+		workers:     config.workers,
+		state:       config.state,
+		verify:      config.verify,
+		verbatim:    config.verbatim && readable && writable,
+	}
+}
+
+// Run migrates the documents, skipping those the state records as already migrated (as of the version listed).
+// Documents which fail to migrate are counted, and are attempted again when the migration is resumed.
+func (this *Migrator) Run() (Report, error) {
+	pending := make(chan persist.DocumentInfo, this.workers)
+	var waiter sync.WaitGroup
+	waiter.Add(this.workers)
+	for i := 0; i < this.workers; i++ {
+		go this.work(pending, &waiter)
+	}
+
+	err := this.source.List(this.prefix, func(info persist.DocumentInfo) error {
+		this.tally(func(report *Report) { report.Listed++ })
+		if this.state != nil && this.state.Done(info.Path, info.Version) {
+			this.tally(func(report *Report) { report.Skipped++ })
+		} else {
+			pending <- info
+		}
+		return nil
+	})
+
+	close(pending)
+	waiter.Wait()
+	return this.report, err
+}
+func (this *Migrator) work(pending chan persist.DocumentInfo, waiter *sync.WaitGroup) {
+	defer waiter.Done()
+
+	for info := range pending {
+		if copied, err := this.migrate(info); err != nil {
+			log.Printf("[WARN] Unable to migrate document [%s]: %s\n", info.Path, err)
+			this.tally(func(report *Report) { report.Failed++ })
+		} else if !copied {
+			this.tally(func(report *Report) { report.Skipped++ })
+		} else {
+			this.tally(func(report *Report) { report.Copied++ })
+		}
+	}
+}
+func (this *Migrator) tally(count func(*Report)) {
+	this.mutex.Lock()
+	count(&this.report)
+	this.mutex.Unlock()
+}
+
+// migrate copies the document, reporting whether it did; a document removed since it was listed isn't copied.
+func (this *Migrator) migrate(info persist.DocumentInfo) (copied bool, err error) {
+	if this.verbatim {
+		copied, err = this.transcribe(info)
+	} else {
+		copied, err = this.recode(info)
+	}
+
+	if err != nil || !copied || this.state == nil {
+		return copied, err
+	}
+
+	return true, this.state.Complete(info.Path, info.Version)
+}
+
+// transcribe copies the document as stored, verifying the checksum of the stored body.
+func (this *Migrator) transcribe(info persist.DocumentInfo) (bool, error) {
+	source, destination := this.source.(persist.StoredReadWriter), this.destination.(persist.StoredReadWriter)
+	original, err := source.ReadStored(persist.NewListedDocument(info))
+	if err != nil {
+		return false, fmt.Errorf("read failed: %s", err)
+	} else if len(original.Body) == 0 {
+		return false, nil // removed since it was listed
+	}
+
+	if err = destination.WriteStored(unversioned(info), original); err != nil {
+		return false, fmt.Errorf("write failed: %s", err)
+	}
+
+	if !this.verify {
+		return true, nil
+	}
+
+	copied, err := destination.ReadStored(unversioned(info))
+	if err != nil {
+		return false, fmt.Errorf("verification read failed: %s", err)
+	} else if expected, actual := sha256.Sum256(original.Body), sha256.Sum256(copied.Body); expected != actual {
+		return false, fmt.Errorf("checksum mismatch: expected %x, found %x", expected, actual)
+	}
+
+	return true, nil
+}
+
+// recode copies the JSON of the document, which the destination encodes as it does all of its documents,
+// verifying the checksum of the JSON.
+func (this *Migrator) recode(info persist.DocumentInfo) (bool, error) {
+	original := persist.NewListedDocument(info)
+	if err := this.source.Read(original); err != nil {
+		return false, fmt.Errorf("read failed: %s", err)
+	} else if len(original.Body()) == 0 {
+		return false, nil // removed since it was listed
+	}
+
+	if err := this.destination.Write(original.Relocate(info.Path)); err != nil {
+		return false, fmt.Errorf("write failed: %s", err)
+	}
+
+	if !this.verify {
+		return true, nil
+	}
+
+	copied := unversioned(info)
+	if err := this.destination.Read(copied); err != nil {
+		return false, fmt.Errorf("verification read failed: %s", err)
+	} else if expected, actual := checksum(original.Body()), checksum(copied.Body()); expected != actual {
+		return false, fmt.Errorf("checksum mismatch: expected %x, found %x", expected, actual)
+	}
+
+	return true, nil
+}
+
+// unversioned stands for the listed document within the destination, where it's written unconditionally.
+func unversioned(info persist.DocumentInfo) *persist.RawDocument {
+	document := persist.NewListedDocument(info)
+	document.Reset()
+	return document
+}
+
+// checksum disregards insignificant whitespace, which encoding the document may remove.
+func checksum(body json.RawMessage) [sha256.Size]byte {
+	buffer := new(bytes.Buffer)
+	if json.Compact(buffer, body) != nil {
+		return sha256.Sum256(body)
+	}
+
+	return sha256.Sum256(buffer.Bytes())
+}
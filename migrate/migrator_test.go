@@ -0,0 +1,248 @@
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist"
+	"github.com/smartystreets/projector/persist/persisttest"
+)
+
+func TestMigratorFixture(t *testing.T) {
+	gunit.Run(new(MigratorFixture), t)
+}
+
+type MigratorFixture struct {
+	*gunit.Fixture
+
+	source      *FakeStorage
+	destination *FakeStorage
+	directory   string
+}
+
+func (this *MigratorFixture) Setup() {
+	this.source = NewFakeStorage()
+	this.source.Put("/a.json", `{"a": 1}`)
+	this.source.Put("/b.json", `{"b":2}`)
+	this.source.Put("/nested/c.json", `{"c":3}`)
+	this.destination = NewFakeStorage()
+	this.directory, _ = ioutil.TempDir("", "migrate")
+}
+func (this *MigratorFixture) Teardown() {
+	_ = os.RemoveAll(this.directory)
+}
+
+func (this *MigratorFixture) TestDocumentsCopiedAndVerified() {
+	report, err := New(this.source, this.destination, Workers(2)).Run()
+
+	this.So(err, should.BeNil)
+	this.So(report, should.Resemble, Report{Listed: 3, Copied: 3})
+	this.So(this.destination.Bodies, should.Resemble, map[string]string{
+		"/a.json":        `{"a":1}`,
+		"/b.json":        `{"b":2}`,
+		"/nested/c.json": `{"c":3}`,
+	})
+	this.So(this.destination.Reads, should.Equal, 3)
+	this.So(this.destination.WrittenVersions, should.Resemble, []interface{}{nil, nil, nil})
+}
+
+func (this *MigratorFixture) TestOnlyDocumentsBeneathPrefixCopied() {
+	report, _ := New(this.source, this.destination, Prefix("/nested/")).Run()
+
+	this.So(report.Copied, should.Equal, 1)
+	this.So(this.destination.Bodies, should.ContainKey, "/nested/c.json")
+}
+
+func (this *MigratorFixture) TestChecksumMismatchFails() {
+	this.destination.corrupt = true
+
+	report, _ := New(this.source, this.destination).Run()
+
+	this.So(report.Failed, should.Equal, 3)
+}
+
+func (this *MigratorFixture) TestVerificationOptional() {
+	this.destination.corrupt = true
+
+	report, _ := New(this.source, this.destination, Verify(false)).Run()
+
+	this.So(report.Copied, should.Equal, 3)
+	this.So(this.destination.Reads, should.Equal, 0)
+}
+
+func (this *MigratorFixture) TestWriteFailureCounted() {
+	this.destination.WriteError = errors.New("BOINK!")
+
+	report, err := New(this.source, this.destination).Run()
+
+	this.So(err, should.BeNil)
+	this.So(report.Failed, should.Equal, 3)
+}
+
+func (this *MigratorFixture) TestListingFailureGivenBack() {
+	this.source.ListError = errors.New("BOINK!")
+
+	_, err := New(this.source, this.destination).Run()
+
+	this.So(err, should.Equal, this.source.ListError)
+}
+
+func (this *MigratorFixture) TestDocumentRemovedSinceListingSkipped() {
+	this.source.vanished = "/b.json"
+
+	report, _ := New(this.source, this.destination).Run()
+
+	this.So(report, should.Resemble, Report{Listed: 3, Skipped: 1, Copied: 2})
+	this.So(this.destination.Bodies, should.NotContainKey, "/b.json")
+}
+
+func (this *MigratorFixture) TestStoredDocumentsCopiedVerbatim() {
+	source, destination := NewFakeStoredStorage(this.source), NewFakeStoredStorage(this.destination)
+	source.stored["/a.json"] = persist.StoredDocument{Body: []byte("sealed"), ContentType: "application/octet-stream"}
+
+	report, _ := New(source, destination, Prefix("/a")).Run()
+
+	this.So(report, should.Resemble, Report{Listed: 1, Copied: 1})
+	this.So(destination.stored["/a.json"], should.Resemble, source.stored["/a.json"])
+	this.So(this.destination.Bodies, should.BeEmpty)
+}
+
+func (this *MigratorFixture) TestStoredDocumentsRecodedWhenAsked() {
+	source, destination := NewFakeStoredStorage(this.source), NewFakeStoredStorage(this.destination)
+
+	report, _ := New(source, destination, Prefix("/a"), Verbatim(false)).Run()
+
+	this.So(report, should.Resemble, Report{Listed: 1, Copied: 1})
+	this.So(destination.stored, should.BeEmpty)
+	this.So(this.destination.Bodies["/a.json"], should.Equal, `{"a":1}`)
+}
+
+func (this *MigratorFixture) TestStoredChecksumMismatchFails() {
+	source, destination := NewFakeStoredStorage(this.source), NewFakeStoredStorage(this.destination)
+	source.stored["/a.json"] = persist.StoredDocument{Body: []byte("sealed")}
+	this.destination.corrupt = true
+
+	report, _ := New(source, destination, Prefix("/a")).Run()
+
+	this.So(report.Failed, should.Equal, 1)
+}
+
+func (this *MigratorFixture) TestMigrationResumed() {
+	filename := filepath.Join(this.directory, "state")
+	_ = ioutil.WriteFile(filename, []byte("/a.json\t"+this.source.Version("/a.json")+"\n"), 0644)
+	state, err := OpenState(filename)
+	this.So(err, should.BeNil)
+
+	report, _ := New(this.source, this.destination, Resume(state)).Run()
+	_ = state.Close()
+
+	this.So(report, should.Resemble, Report{Listed: 3, Skipped: 1, Copied: 2})
+	this.So(this.destination.Bodies, should.NotContainKey, "/a.json")
+
+	resumed, _ := OpenState(filename)
+	defer func() { _ = resumed.Close() }()
+	for _, path := range []string{"/a.json", "/b.json", "/nested/c.json"} {
+		this.So(resumed.Done(path, this.source.Version(path)), should.BeTrue)
+	}
+}
+
+func (this *MigratorFixture) TestDocumentChangedSinceMigratedCopiedAgain() {
+	filename := filepath.Join(this.directory, "state")
+	_ = ioutil.WriteFile(filename, []byte("/a.json\tv1\n/b.json\tv1\n"), 0644)
+	state, _ := OpenState(filename)
+	this.source.listedVersions = map[string]string{"/a.json": "v1", "/b.json": "v2", "/nested/c.json": "v1"}
+
+	report, _ := New(this.source, this.destination, Resume(state)).Run()
+	_ = state.Close()
+
+	this.So(report, should.Resemble, Report{Listed: 3, Skipped: 1, Copied: 2})
+	this.So(this.destination.Bodies, should.ContainKey, "/b.json")
+
+	resumed, _ := OpenState(filename)
+	defer func() { _ = resumed.Close() }()
+	this.So(resumed.Done("/b.json", "v2"), should.BeTrue)
+	this.So(resumed.Done("/nested/c.json", "v1"), should.BeTrue)
+}
+
+func (this *MigratorFixture) TestFailedDocumentsNotRecordedAsMigrated() {
+	filename := filepath.Join(this.directory, "state")
+	state, _ := OpenState(filename)
+	this.destination.WriteError = errors.New("BOINK!")
+
+	_, _ = New(this.source, this.destination, Resume(state)).Run()
+	_ = state.Close()
+
+	raw, _ := ioutil.ReadFile(filename)
+	this.So(raw, should.BeEmpty)
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+// FakeStorage corrupts what it reads, removes a document as it's listed, or lists other versions, when asked to.
+type FakeStorage struct {
+	*persisttest.Storage
+
+	corrupt        bool
+	vanished       string
+	listedVersions map[string]string
+}
+
+func NewFakeStorage() *FakeStorage {
+	return &FakeStorage{Storage: persisttest.NewStorage("fake")}
+}
+
+func (this *FakeStorage) Read(document projector.Document) error {
+	if err := this.Storage.Read(document); err != nil || !this.corrupt || document.Version() == nil {
+		return err
+	}
+
+	return json.Unmarshal([]byte(`{"corrupt":true}`), document)
+}
+func (this *FakeStorage) List(prefix string, visit func(persist.DocumentInfo) error) error {
+	return this.Storage.List(prefix, func(info persist.DocumentInfo) error {
+		if version, found := this.listedVersions[info.Path]; found {
+			info.Version = version
+		}
+		if info.Path == this.vanished {
+			_ = this.Storage.Delete(persist.NewRawDocument(info.Path))
+		}
+		return visit(info)
+	})
+}
+
+type FakeStoredStorage struct {
+	*FakeStorage
+
+	mutex  sync.Mutex
+	stored map[string]persist.StoredDocument
+}
+
+func NewFakeStoredStorage(storage *FakeStorage) *FakeStoredStorage {
+	return &FakeStoredStorage{FakeStorage: storage, stored: map[string]persist.StoredDocument{}}
+}
+
+func (this *FakeStoredStorage) ReadStored(document projector.Document) (persist.StoredDocument, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	stored := this.stored[document.Path()]
+	if this.corrupt {
+		stored.Body = []byte("corrupt")
+	}
+	return stored, nil
+}
+func (this *FakeStoredStorage) WriteStored(document projector.Document, stored persist.StoredDocument) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.stored[document.Path()] = stored
+	return nil
+}
@@ -0,0 +1,49 @@
+package migrate
+
+type Option func(*configuration)
+
+type configuration struct {
+	prefix   string
+	workers  int
+	state    *State
+	verify   bool
+	verbatim bool
+}
+
+func newConfiguration(options []Option) configuration {
+	config := configuration{prefix: "/", workers: 8, verify: true, verbatim: true}
+	for _, option := range options {
+		option(&config)
+	}
+	return config
+}
+
+// Prefix migrates only the documents whose paths begin with the prefix.
+func Prefix(prefix string) Option {
+	return func(this *configuration) { this.prefix = prefix }
+}
+
+// Workers bounds the number of documents migrated at once.
+func Workers(count int) Option {
+	return func(this *configuration) {
+		if count > 0 {
+			this.workers = count
+		}
+	}
+}
+
+// Resume skips the documents already migrated according to the state, recording those migrated now.
+func Resume(state *State) Option {
+	return func(this *configuration) { this.state = state }
+}
+
+// Verify reads each document back from the destination to compare its checksum with the original.
+func Verify(verify bool) Option {
+	return func(this *configuration) { this.verify = verify }
+}
+
+// Verbatim copies documents as the source stores them, where both storages are able to, which is the default.
+// Otherwise each document is encoded as the destination stores its documents, such as to encrypt them.
+func Verbatim(verbatim bool) Option {
+	return func(this *configuration) { this.verbatim = verbatim }
+}
@@ -0,0 +1,82 @@
+package migrate
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// State remembers the path and version of each document migrated in a file, such that an interrupted migration
+// may be resumed without copying those documents again unless they've since changed.
+type State struct {
+	mutex sync.Mutex
+	file  *os.File
+	done  map[string]string
+}
+
+// OpenState loads the documents already migrated from the file, creating it if need be.
+func OpenState(filename string) (*State, error) {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	done := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); len(line) > 0 {
+			path, version := split(line)
+			done[path] = version
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &State{file: file, done: done}, nil
+}
+func split(line string) (path, version string) {
+	if index := strings.LastIndex(line, "\t"); index >= 0 {
+		return line[:index], line[index+1:]
+	}
+
+	return line, ""
+}
+
+// Done reports whether the document at the path was already migrated as of the version given. Where the
+// source doesn't list versions, having migrated the document at all suffices.
+func (this *State) Done(path string, version interface{}) bool {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	migrated, found := this.done[path]
+	return found && migrated == format(version)
+}
+
+// Complete records that the document at the path has been migrated as of the version given.
+func (this *State) Complete(path string, version interface{}) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if _, err := this.file.WriteString(path + "\t" + format(version) + "\n"); err != nil {
+		return err
+	}
+
+	this.done[path] = format(version)
+	return this.file.Sync()
+}
+
+func (this *State) Close() error {
+	return this.file.Close()
+}
+
+func format(version interface{}) string {
+	if version == nil {
+		return ""
+	}
+
+	return fmt.Sprint(version)
+}
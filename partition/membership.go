@@ -0,0 +1,162 @@
+package partition
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/smartystreets/projector/persist"
+)
+
+// Membership divides the documents among the members listed by a roster document, which each renews periodically,
+// by consistent hashing of their paths. A member keeps reassigned documents for a while, overlapping their new owner.
+type Membership struct {
+	storage  persist.ReadWriter
+	path     string
+	member   string
+	now      func() time.Time
+	duration time.Duration
+	interval time.Duration
+	replicas int
+	shutdown chan struct{}
+
+	mutex    sync.RWMutex
+	members  []string
+	ring     *ring
+	previous *ring
+	handoff  time.Time
+	expires  time.Time
+}
+
+func New(storage persist.ReadWriter, path, member string, now func() time.Time) *Membership {
+	return &Membership{
+		storage:  storage,
+		path:     path,
+		member:   member,
+		now:      now,
+		duration: time.Second * 30,
+		interval: time.Second * 10,
+		replicas: 64,
+		shutdown: make(chan struct{}),
+		ring:     newRing(nil, 0),
+		previous: newRing(nil, 0),
+	}
+}
+
+// WithDuration sets how long membership lasts once joined or renewed (by default, 30 seconds).
+func (this *Membership) WithDuration(duration time.Duration) *Membership {
+	this.duration = duration
+	return this
+}
+
+// WithInterval sets the time between renewals of membership when run in the background by Listen
+// (by default, 10 seconds), which should be well within its duration.
+func (this *Membership) WithInterval(interval time.Duration) *Membership {
+	this.interval = interval
+	return this
+}
+
+// Listen joins, or renews membership once joined, immediately and then periodically until it is closed,
+// whereupon the member leaves such that its documents are reassigned without waiting for it to expire.
+func (this *Membership) Listen() {
+	for {
+		if err := this.Join(); err != nil {
+			log.Printf("[WARN] Unable to renew membership of [%s] within [%s]: %s\n", this.member, this.path, err)
+		}
+
+		select {
+		case <-this.shutdown:
+			if err := this.Leave(); err != nil {
+				log.Printf("[WARN] Unable to withdraw membership of [%s] within [%s]: %s\n", this.member, this.path, err)
+			}
+			return
+		case <-time.After(this.interval):
+		}
+	}
+}
+func (this *Membership) Close() {
+	close(this.shutdown)
+}
+
+// Join adds the member to the roster, or renews its membership, forgetting any members whose membership has
+// expired, and then assigns the documents among the members listed.
+func (this *Membership) Join() error {
+	return this.update(func(roster *roster, now time.Time) {
+		roster.Members[this.member] = now.Add(this.duration)
+	})
+}
+
+// Leave removes the member from the roster, after which it owns no documents.
+func (this *Membership) Leave() error {
+	return this.update(func(roster *roster, now time.Time) {
+		delete(roster.Members, this.member)
+	})
+}
+
+func (this *Membership) update(change func(*roster, time.Time)) error {
+	for {
+		now := this.now()
+		roster := newRoster(this.path)
+		if err := this.storage.Read(roster); err != nil {
+			return err
+		} else if roster.Version() == nil {
+			roster.SetVersion(persist.Absent) // members joining at once create it only once
+		}
+
+		roster.prune(now)
+		change(roster, now)
+
+		if err := this.storage.Write(roster); err == persist.ErrConcurrentWrite {
+			continue // another member has since renewed; renew upon its changes
+		} else if err != nil {
+			return err
+		}
+
+		this.assign(roster.names(), roster.Members[this.member], now)
+		return nil
+	}
+}
+
+// assign rebuilds the ring once the members have changed, keeping the previous ring for the handoff.
+func (this *Membership) assign(members []string, expires, now time.Time) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.expires = expires
+	if strings.Join(members, "\n") == strings.Join(this.members, "\n") {
+		return
+	}
+
+	log.Printf("[INFO] Documents reassigned among members of [%s]: %v\n", this.path, members)
+	this.members = members
+	this.previous, this.handoff = this.ring, now.Add(this.duration)
+	this.ring = newRing(members, this.replicas)
+}
+
+// Members gives the members among which the documents were last assigned.
+func (this *Membership) Members() []string {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	return append([]string(nil), this.members...)
+}
+
+// Owns reports whether the document at the path is assigned to this member, or was until recently. A member
+// which has yet to join, or has failed to renew, owns nothing and gives back ErrMembershipLapsed.
+func (this *Membership) Owns(path string) (bool, error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	now := this.now()
+	if !now.Before(this.expires) {
+		return false, ErrMembershipLapsed
+	} else if this.ring.owner(path) == this.member {
+		return true, nil
+	}
+
+	return now.Before(this.handoff) && this.previous.owner(path) == this.member, nil
+}
+
+var ErrMembershipLapsed = errors.New("this process isn't a member of the partition, or its membership has lapsed")
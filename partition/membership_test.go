@@ -0,0 +1,174 @@
+package partition
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector/persist/persisttest"
+)
+
+func TestMembershipFixture(t *testing.T) {
+	gunit.Run(new(MembershipFixture), t)
+}
+
+type MembershipFixture struct {
+	*gunit.Fixture
+
+	now     time.Time
+	storage *persisttest.Storage
+	first   *Membership
+	second  *Membership
+}
+
+func (this *MembershipFixture) Setup() {
+	this.now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	this.storage = persisttest.NewStorage("fake")
+	this.first = New(this.storage, "/members.json", "first", this.clock).WithDuration(time.Minute)
+	this.second = New(this.storage, "/members.json", "second", this.clock).WithDuration(time.Minute)
+}
+func (this *MembershipFixture) clock() time.Time { return this.now }
+
+func (this *MembershipFixture) TestSoleMemberOwnsEverything() {
+	err := this.first.Join()
+
+	this.So(err, should.BeNil)
+	this.So(this.first.Members(), should.Resemble, []string{"first"})
+	this.So(owns(this.first, "/a"), should.BeTrue)
+	this.So(owns(this.first, "/b"), should.BeTrue)
+	this.So(this.storage.Bodies["/members.json"], should.Equal, `{"members":{"first":"2020-01-01T00:01:00Z"}}`)
+}
+
+func (this *MembershipFixture) TestNothingOwnedBeforeJoining() {
+	owned, err := this.first.Owns("/a")
+
+	this.So(owned, should.BeFalse)
+	this.So(err, should.Equal, ErrMembershipLapsed)
+}
+
+func (this *MembershipFixture) TestDocumentsDividedAmongMembers() {
+	_ = this.first.Join()
+	_ = this.second.Join()
+	_ = this.first.Join()
+	this.renewPastHandoff()
+
+	this.So(this.first.Members(), should.Resemble, []string{"first", "second"})
+	for i := 0; i < 100; i++ {
+		path := fmt.Sprintf("/documents/%d.json", i)
+		this.So(owns(this.first, path), should.NotEqual, owns(this.second, path))
+	}
+}
+
+func (this *MembershipFixture) TestExpiredMembersForgotten() {
+	_ = this.second.Join()
+	this.now = this.now.Add(time.Minute)
+
+	_ = this.first.Join()
+
+	this.So(this.first.Members(), should.Resemble, []string{"first"})
+	this.So(owns(this.second, "/a"), should.BeFalse)
+}
+
+func (this *MembershipFixture) TestMemberLeaving() {
+	_ = this.first.Join()
+	_ = this.second.Join()
+
+	err := this.second.Leave()
+	_ = this.first.Join()
+
+	this.So(err, should.BeNil)
+	this.So(owns(this.second, "/a"), should.BeFalse)
+	this.So(this.first.Members(), should.Resemble, []string{"first"})
+}
+
+func (this *MembershipFixture) TestConcurrentRenewalRetried() {
+	_ = this.first.Join()
+	this.storage.BeforeWrite = func() {
+		this.storage.BeforeWrite = nil
+		_ = this.second.Join()
+	}
+
+	err := this.first.Join()
+
+	this.So(err, should.BeNil)
+	this.So(this.first.Members(), should.Resemble, []string{"first", "second"})
+}
+
+func (this *MembershipFixture) TestAssignmentKeptUntilExpiredWhenRosterUnavailable() {
+	_ = this.first.Join()
+	this.storage.ReadError = errors.New("BOINK!")
+
+	err := this.first.Join()
+
+	this.So(err, should.Equal, this.storage.ReadError)
+	this.So(owns(this.first, "/a"), should.BeTrue)
+	this.now = this.now.Add(time.Minute)
+	owned, err := this.first.Owns("/a")
+	this.So(owned, should.BeFalse)
+	this.So(err, should.Equal, ErrMembershipLapsed)
+}
+
+func (this *MembershipFixture) TestReassignedDocumentsKeptForHandoff() {
+	_ = this.first.Join()
+	_ = this.second.Join()
+	path := this.pathOwnedBy(this.second)
+
+	_ = this.first.Join()
+	this.So(owns(this.first, path), should.BeTrue) // until the second has surely learned of it
+
+	this.renewPastHandoff()
+	this.So(owns(this.first, path), should.BeFalse)
+	this.So(owns(this.second, path), should.BeTrue)
+}
+
+// renewPastHandoff renews both memberships until the documents reassigned by the last change are handed off.
+func (this *MembershipFixture) renewPastHandoff() {
+	this.now = this.now.Add(time.Second * 30)
+	_ = this.first.Join()
+	_ = this.second.Join()
+	this.now = this.now.Add(time.Second * 30)
+}
+func (this *MembershipFixture) pathOwnedBy(member *Membership) string {
+	for i := 0; ; i++ {
+		if path := fmt.Sprintf("/documents/%d.json", i); owns(member, path) {
+			return path
+		}
+	}
+}
+
+func (this *MembershipFixture) TestRacingCreationOfRosterRetried() {
+	this.storage.BeforeWrite = func() {
+		this.storage.BeforeWrite = nil
+		_ = this.second.Join() // both find no roster, and so create it only if it's still absent
+	}
+
+	err := this.first.Join()
+
+	this.So(err, should.BeNil)
+	this.So(this.first.Members(), should.Resemble, []string{"first", "second"})
+}
+
+func (this *MembershipFixture) TestListenJoinsUntilClosedThenLeaves() {
+	membership := New(this.storage, "/members.json", "first", time.Now).WithInterval(time.Millisecond)
+	var waiter sync.WaitGroup
+	waiter.Add(1)
+	go func() { membership.Listen(); waiter.Done() }()
+
+	for !owns(membership, "/a") {
+		time.Sleep(time.Millisecond)
+	}
+	membership.Close()
+	waiter.Wait()
+
+	this.So(owns(membership, "/a"), should.BeFalse)
+	this.So(this.storage.Body("/members.json"), should.Equal, `{"members":{}}`)
+}
+
+func owns(membership *Membership, path string) bool {
+	owned, _ := membership.Owns(path)
+	return owned
+}
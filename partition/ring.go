@@ -0,0 +1,49 @@
+package partition
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"sort"
+	"strconv"
+)
+
+// ring assigns keys to members by consistent hashing, such that a member joining or leaving
+// moves only the keys it gains or loses. Each member has many points on the ring to even out its share.
+type ring struct {
+	points  []uint64
+	members map[uint64]string
+}
+
+func newRing(members []string, replicas int) *ring {
+	this := &ring{members: map[uint64]string{}}
+	for _, member := range members {
+		for i := 0; i < replicas; i++ {
+			point := hash(member + "#" + strconv.Itoa(i))
+			this.points = append(this.points, point)
+			this.members[point] = member
+		}
+	}
+
+	sort.Slice(this.points, func(i, j int) bool { return this.points[i] < this.points[j] })
+	return this
+}
+
+// owner gives the member at the first point on the ring at or beyond the key, or blank when there are no members.
+func (this *ring) owner(key string) string {
+	if len(this.points) == 0 {
+		return ""
+	}
+
+	point := hash(key)
+	i := sort.Search(len(this.points), func(i int) bool { return this.points[i] >= point })
+	if i == len(this.points) {
+		i = 0
+	}
+
+	return this.members[this.points[i]]
+}
+
+func hash(value string) uint64 {
+	sum := md5.Sum([]byte(value))
+	return binary.BigEndian.Uint64(sum[:8])
+}
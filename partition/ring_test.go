@@ -0,0 +1,47 @@
+package partition
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestRingFixture(t *testing.T) {
+	gunit.Run(new(RingFixture), t)
+}
+
+type RingFixture struct {
+	*gunit.Fixture
+}
+
+func (this *RingFixture) TestEmptyRingHasNoOwner() {
+	this.So(newRing(nil, 64).owner("/document"), should.BeBlank)
+}
+
+func (this *RingFixture) TestKeysSharedAmongMembers() {
+	shares := map[string]int{}
+	ring := newRing([]string{"a", "b", "c"}, 64)
+
+	for i := 0; i < 3000; i++ {
+		shares[ring.owner(fmt.Sprintf("/documents/%d.json", i))]++
+	}
+
+	this.So(shares, should.HaveLength, 3)
+	for _, share := range shares {
+		this.So(share, should.BeBetween, 500, 1500)
+	}
+}
+
+func (this *RingFixture) TestOnlyKeysOfDepartedMemberReassigned() {
+	before := newRing([]string{"a", "b", "c"}, 64)
+	after := newRing([]string{"a", "c"}, 64)
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("/documents/%d.json", i)
+		if owner := before.owner(key); owner != "b" {
+			this.So(after.owner(key), should.Equal, owner)
+		}
+	}
+}
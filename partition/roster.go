@@ -0,0 +1,45 @@
+package partition
+
+import (
+	"sort"
+	"time"
+
+	"github.com/smartystreets/projector"
+)
+
+// roster is the document listing the members and when the membership of each expires unless renewed.
+type roster struct {
+	projector.VersionInfo
+
+	path string
+
+	Members map[string]time.Time `json:"members"`
+}
+
+func newRoster(path string) *roster {
+	return &roster{path: path, Members: map[string]time.Time{}}
+}
+
+func (this *roster) Lapse(time.Time) projector.Document { return this }
+func (this *roster) Apply(interface{}) bool             { return false }
+func (this *roster) Path() string                       { return this.path }
+func (this *roster) Reset() {
+	this.Members = map[string]time.Time{}
+	this.VersionInfo.Reset()
+}
+
+// prune forgets the members whose membership has expired.
+func (this *roster) prune(now time.Time) {
+	for member, expires := range this.Members {
+		if !now.Before(expires) {
+			delete(this.Members, member)
+		}
+	}
+}
+func (this *roster) names() (names []string) {
+	for member := range this.Members {
+		names = append(names, member)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,55 @@
+package aliaspersist
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	"github.com/smartystreets/projector"
+)
+
+// Alias is the pointer document naming the generation (the path prefix, e.g. "v7") beneath
+// which the documents currently in use are found, along with the generation it replaced.
+type Alias struct {
+	projector.VersionInfo
+
+	path string
+
+	Generation string    `json:"generation"`
+	Previous   string    `json:"previous,omitempty"`
+	Published  time.Time `json:"published"`
+}
+
+func newAlias(path string) *Alias {
+	return &Alias{path: path}
+}
+
+func (this *Alias) Lapse(time.Time) projector.Document { return this }
+func (this *Alias) Apply(interface{}) bool             { return false }
+func (this *Alias) Path() string                       { return this.path }
+func (this *Alias) Reset() {
+	this.Generation, this.Previous, this.Published = "", "", time.Time{}
+	this.VersionInfo.Reset()
+}
+
+// resolve gives the path of the document within the generation, or the path itself when nothing has been published.
+func (this *Alias) resolve(document projector.Document) string {
+	if len(this.Generation) == 0 {
+		return document.Path()
+	}
+
+	return path.Join("/", this.Generation, document.Path())
+}
+
+// prefix gives the path beneath which the documents of the generation are stored, if any.
+func (this *Alias) prefix() string {
+	if len(this.Generation) == 0 {
+		return ""
+	}
+
+	return "/" + this.Generation
+}
+
+func cleanGeneration(generation string) string {
+	return strings.Trim(strings.TrimSpace(generation), "/")
+}
@@ -0,0 +1,104 @@
+package aliaspersist
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/smartystreets/projector/persist"
+)
+
+// Publisher flips the alias from one generation to another. Each flip is a conditional write of the alias
+// which fails with persist.ErrConcurrentWrite when another process has published in the meantime.
+type Publisher struct {
+	storage persist.ReadWriter
+	alias   string
+	now     func() time.Time
+}
+
+func NewPublisher(storage persist.ReadWriter, alias string, now func() time.Time) *Publisher {
+	return &Publisher{storage: storage, alias: alias, now: now}
+}
+
+// Current gives the generation presently published, which is blank when none has been.
+func (this *Publisher) Current() (string, error) {
+	alias, err := this.read()
+	if err != nil {
+		return "", err
+	}
+
+	return alias.Generation, nil
+}
+
+// Publish flips the alias to the generation, giving back the generation it replaced.
+func (this *Publisher) Publish(generation string) (previous string, err error) {
+	if generation = cleanGeneration(generation); len(generation) == 0 {
+		return "", ErrBlankGeneration
+	}
+
+	alias, err := this.read()
+	if err != nil {
+		return "", err
+	}
+
+	return this.flip(alias, generation)
+}
+
+// Flip publishes the generation only when the one expected (blank for none) is still current.
+func (this *Publisher) Flip(expected, generation string) error {
+	if generation = cleanGeneration(generation); len(generation) == 0 {
+		return ErrBlankGeneration
+	}
+
+	alias, err := this.read()
+	if err != nil {
+		return err
+	} else if alias.Generation != cleanGeneration(expected) {
+		return persist.ErrConcurrentWrite
+	}
+
+	_, err = this.flip(alias, generation)
+	return err
+}
+
+// Rollback restores the generation which the current one replaced.
+func (this *Publisher) Rollback() (string, error) {
+	alias, err := this.read()
+	if err != nil {
+		return "", err
+	} else if len(alias.Previous) == 0 {
+		return "", ErrNothingToRollBack
+	}
+
+	return this.flip(alias, alias.Previous)
+}
+
+func (this *Publisher) read() (*Alias, error) {
+	alias := newAlias(this.alias)
+	if err := this.storage.Read(alias); err != nil {
+		return nil, err
+	} else if alias.Version() == nil {
+		alias.SetVersion(persist.Absent) // should several publish the first generation at once, only one does
+	}
+
+	return alias, nil
+}
+func (this *Publisher) flip(alias *Alias, generation string) (string, error) {
+	previous := alias.Generation
+	if previous == generation {
+		return previous, nil
+	}
+
+	alias.Generation, alias.Previous, alias.Published = generation, previous, this.now()
+	if err := this.storage.Write(alias); err != nil {
+		return "", err
+	}
+
+	log.Printf("[INFO] Alias [%s] flipped from generation [%s] to [%s].\n", this.alias, previous, generation)
+	return previous, nil
+}
+
+var (
+	ErrBlankGeneration   = errors.New("a generation to publish must be specified")
+	ErrNothingToRollBack = errors.New("the alias names no previous generation to roll back to")
+)
@@ -0,0 +1,128 @@
+package aliaspersist
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist"
+)
+
+// ReadWriter follows the alias, reading and writing each document within the generation it names. The alias
+// is read again once the refresh interval has passed, after which writes of documents read from the previous
+// generation fail as concurrent writes (their versions no longer match) and are applied afresh.
+type ReadWriter struct {
+	persist.ReadWriter
+
+	alias   string
+	now     func() time.Time
+	refresh time.Duration
+	mutex   sync.Mutex
+	current *Alias
+	expires time.Time
+}
+
+func NewReadWriter(storage persist.ReadWriter, alias string, now func() time.Time) *ReadWriter {
+	return &ReadWriter{ReadWriter: storage, alias: alias, now: now, refresh: time.Second * 30}
+}
+
+// WithRefresh sets how long the alias is trusted before it's read again.
+func (this *ReadWriter) WithRefresh(interval time.Duration) *ReadWriter {
+	this.refresh = interval
+	return this
+}
+
+func (this *ReadWriter) ReadPanic(document projector.Document) {
+	if err := this.Read(document); err != nil {
+		log.Panic(err)
+	}
+}
+func (this *ReadWriter) Read(document projector.Document) error {
+	alias, err := this.follow()
+	if err != nil {
+		return err
+	}
+
+	return this.ReadWriter.Read(persist.Relocate(document, alias.resolve(document)))
+}
+func (this *ReadWriter) Write(document projector.Document) error {
+	alias, err := this.follow()
+	if err != nil {
+		return err
+	}
+
+	return this.ReadWriter.Write(persist.Relocate(document, alias.resolve(document)))
+}
+
+// Delete removes the document from the generation it names.
+func (this *ReadWriter) Delete(document projector.Document) error {
+	deleter, ok := this.ReadWriter.(persist.Deleter)
+	if !ok {
+		return fmt.Errorf("storage [%s] is unable to delete documents", this.ReadWriter.Name())
+	}
+
+	alias, err := this.follow()
+	if err != nil {
+		return err
+	}
+
+	return deleter.Delete(persist.Relocate(document, alias.resolve(document)))
+}
+
+// List enumerates the documents of the generation it names, by their paths within the generation.
+func (this *ReadWriter) List(prefix string, visit func(persist.DocumentInfo) error) error {
+	lister, ok := this.ReadWriter.(persist.Lister)
+	if !ok {
+		return fmt.Errorf("storage [%s] is unable to list documents", this.ReadWriter.Name())
+	}
+
+	alias, err := this.follow()
+	if err != nil {
+		return err
+	}
+
+	generation := alias.prefix()
+	if len(generation) == 0 {
+		return lister.List(prefix, visit)
+	}
+
+	return lister.List(generation+"/"+strings.TrimPrefix(prefix, "/"), func(info persist.DocumentInfo) error {
+		info.Path = strings.TrimPrefix(info.Path, generation)
+		return visit(info)
+	})
+}
+
+// Generation gives the generation currently followed.
+func (this *ReadWriter) Generation() (string, error) {
+	alias, err := this.follow()
+	if err != nil {
+		return "", err
+	}
+
+	return alias.Generation, nil
+}
+
+func (this *ReadWriter) follow() (*Alias, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	now := this.now()
+	if this.current != nil && now.Before(this.expires) {
+		return this.current, nil
+	}
+
+	alias := newAlias(this.alias)
+	if err := this.ReadWriter.Read(alias); err != nil {
+		return nil, err
+	}
+
+	if this.current != nil && this.current.Generation != alias.Generation {
+		log.Printf("[INFO] Alias [%s] now names generation [%s].\n", this.alias, alias.Generation)
+	}
+
+	this.current, this.expires = alias, now.Add(this.refresh)
+	return alias, nil
+}
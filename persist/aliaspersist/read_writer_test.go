@@ -0,0 +1,188 @@
+package aliaspersist
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist"
+	"github.com/smartystreets/projector/persist/persisttest"
+)
+
+func TestAliasFixture(t *testing.T) {
+	gunit.Run(new(AliasFixture), t)
+}
+
+type AliasFixture struct {
+	*gunit.Fixture
+
+	now       time.Time
+	storage   *persisttest.Storage
+	publisher *Publisher
+	follower  *ReadWriter
+}
+
+func (this *AliasFixture) Setup() {
+	this.now = time.Date(2020, 6, 30, 0, 0, 0, 0, time.UTC)
+	this.storage = persisttest.NewStorage("fake")
+	this.publisher = NewPublisher(this.storage, "/current.json", this.clock)
+	this.follower = NewReadWriter(this.storage, "/current.json", this.clock).WithRefresh(time.Minute)
+}
+func (this *AliasFixture) clock() time.Time { return this.now }
+
+func (this *AliasFixture) TestUnpublishedAliasLeavesPathsAlone() {
+	document := &FakeDocument{Total: 1}
+
+	this.So(this.follower.Write(document), should.BeNil)
+
+	this.So(this.storage.Bodies, should.ContainKey, "/totals.json")
+}
+
+func (this *AliasFixture) TestDocumentsFollowPublishedGeneration() {
+	previous, err := this.publisher.Publish("/v7/")
+	this.So(err, should.BeNil)
+	this.So(previous, should.BeBlank)
+
+	_ = this.follower.Write(&FakeDocument{Total: 7})
+
+	this.So(this.storage.Bodies["/v7/totals.json"], should.Equal, `{"Total":7}`)
+	this.So(this.storage.Bodies["/current.json"], should.ContainSubstring, `"generation":"v7"`)
+}
+
+func (this *AliasFixture) TestReadFollowsGenerationAndKeepsVersion() {
+	this.storage.Bodies["/v7/totals.json"] = `{"Total":7}`
+	_, _ = this.publisher.Publish("v7")
+	document := &FakeDocument{}
+
+	_ = this.follower.Read(document)
+
+	this.So(document.Total, should.Equal, 7)
+	this.So(document.Version(), should.Equal, this.storage.Version("/v7/totals.json"))
+}
+
+func (this *AliasFixture) TestAliasReadAgainOnceRefreshIntervalPasses() {
+	_, _ = this.publisher.Publish("v7")
+	_, _ = this.follower.Generation()
+	_, _ = this.publisher.Publish("v8")
+
+	before, _ := this.follower.Generation()
+	this.now = this.now.Add(time.Minute)
+	after, _ := this.follower.Generation()
+
+	this.So(before, should.Equal, "v7")
+	this.So(after, should.Equal, "v8")
+}
+
+func (this *AliasFixture) TestWriteOfDocumentFromPreviousGenerationConflicts() {
+	this.storage.Put("/v7/totals.json", `{"Total":7}`)
+	this.storage.Put("/v8/totals.json", `{"Total":8}`)
+	_, _ = this.publisher.Publish("v7")
+	document := &FakeDocument{}
+	_ = this.follower.Read(document)
+
+	_, _ = this.publisher.Publish("v8")
+	this.now = this.now.Add(time.Minute)
+
+	this.So(this.follower.Write(document), should.Equal, persist.ErrConcurrentWrite)
+}
+
+func (this *AliasFixture) TestPublishRecordsPreviousGenerationForRollback() {
+	_, _ = this.publisher.Publish("v7")
+	this.now = this.now.Add(time.Hour)
+	previous, _ := this.publisher.Publish("v8")
+
+	restored, err := this.publisher.Rollback()
+	current, _ := this.publisher.Current()
+
+	this.So(previous, should.Equal, "v7")
+	this.So(err, should.BeNil)
+	this.So(restored, should.Equal, "v8")
+	this.So(current, should.Equal, "v7")
+}
+
+func (this *AliasFixture) TestRollbackWithoutPreviousGeneration() {
+	_, err := this.publisher.Rollback()
+
+	this.So(err, should.Equal, ErrNothingToRollBack)
+}
+
+func (this *AliasFixture) TestFlipOnlyFromExpectedGeneration() {
+	_, _ = this.publisher.Publish("v7")
+
+	this.So(this.publisher.Flip("v6", "v8"), should.Equal, persist.ErrConcurrentWrite)
+	this.So(this.publisher.Flip("v7", "v8"), should.BeNil)
+
+	current, _ := this.publisher.Current()
+	this.So(current, should.Equal, "v8")
+}
+
+func (this *AliasFixture) TestConcurrentPublicationRejected() {
+	_, _ = this.publisher.Publish("v7")
+	this.storage.WriteError = persist.ErrConcurrentWrite
+
+	_, err := this.publisher.Publish("v8")
+
+	this.So(err, should.Equal, persist.ErrConcurrentWrite)
+}
+
+func (this *AliasFixture) TestRacingFirstPublicationWonByOnlyOne() {
+	rival := NewPublisher(this.storage, "/current.json", this.clock)
+	this.storage.BeforeWrite = func() {
+		this.storage.BeforeWrite = nil
+		_, _ = rival.Publish("v8")
+	}
+
+	_, err := this.publisher.Publish("v7")
+
+	current, _ := this.publisher.Current()
+	this.So(err, should.Equal, persist.ErrConcurrentWrite)
+	this.So(current, should.Equal, "v8")
+}
+
+func (this *AliasFixture) TestBlankGenerationRejected() {
+	_, err := this.publisher.Publish(" / ")
+
+	this.So(err, should.Equal, ErrBlankGeneration)
+	this.So(this.publisher.Flip("", ""), should.Equal, ErrBlankGeneration)
+}
+
+func (this *AliasFixture) TestDeleteFollowsPublishedGeneration() {
+	this.storage.Bodies["/v7/totals.json"], this.storage.Bodies["/totals.json"] = `{"Total":7}`, `{"Total":1}`
+	_, _ = this.publisher.Publish("v7")
+
+	this.So(this.follower.Delete(&FakeDocument{}), should.BeNil)
+
+	this.So(this.storage.Bodies, should.NotContainKey, "/v7/totals.json")
+	this.So(this.storage.Bodies, should.ContainKey, "/totals.json")
+}
+
+func (this *AliasFixture) TestListEnumeratesPublishedGenerationByPathsWithin() {
+	this.storage.Bodies["/v7/totals.json"], this.storage.Bodies["/v7/a/b.json"] = `{}`, `{}`
+	this.storage.Bodies["/v70/totals.json"], this.storage.Bodies["/totals.json"] = `{}`, `{}`
+	_, _ = this.publisher.Publish("v7")
+
+	var paths []string
+	err := this.follower.List("/", func(info persist.DocumentInfo) error {
+		paths = append(paths, info.Path)
+		return nil
+	})
+
+	sort.Strings(paths)
+	this.So(err, should.BeNil)
+	this.So(paths, should.Resemble, []string{"/a/b.json", "/totals.json"})
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+type FakeDocument struct {
+	projector.VersionInfo
+
+	Total int
+}
+
+func (this *FakeDocument) Lapse(now time.Time) projector.Document { return this }
+func (this *FakeDocument) Apply(message interface{}) bool         { return false }
+func (this *FakeDocument) Path() string                           { return "/totals.json" }
@@ -0,0 +1,325 @@
+package anypersist
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/smartystreets/gcs"
+	"github.com/smartystreets/projector/persist"
+	"github.com/smartystreets/projector/persist/credentials"
+	"github.com/smartystreets/projector/persist/envelope"
+	"github.com/smartystreets/projector/persist/readcache"
+)
+
+// Config declares the storage to build. It is loaded from a JSON file, whose fields are named by the
+// tags below, and from environment variables, which are named by upper-casing the same names beneath
+// a prefix (e.g. "PROJECTOR_" gives PROJECTOR_ENGINE, PROJECTOR_PATH_PREFIX, and so on).
+type Config struct {
+	Engine     string `json:"engine"` // "s3" or "gcs"
+	PathPrefix string `json:"path_prefix"`
+	Namespace  string `json:"namespace"`
+
+	// Credentials names where they're found: "" for those given below, "environment", or,
+	// for S3 only, "shared_file" or "endpoint" (see the credentials package).
+	Credentials              string `json:"credentials"`
+	Profile                  string `json:"profile"`
+	SharedCredentialsFile    string `json:"shared_credentials_file"`
+	CredentialsEndpoint      string `json:"credentials_endpoint"`
+	CredentialsAuthorization string `json:"credentials_authorization"`
+
+	Address              string `json:"address"` // S3 only, e.g. "https://bucket.s3-us-west-1.amazonaws.com/"
+	AccessKey            string `json:"access_key"`
+	SecretKey            string `json:"secret_key"`
+	SessionToken         string `json:"session_token"`          // for temporary access keys only
+	ServerSideEncryption string `json:"server_side_encryption"` // "AES256" (the default), "aws:kms", or "none"
+	KMSKeyID             string `json:"kms_key_id"`
+
+	Bucket                string `json:"bucket"`                   // Google Cloud Storage only
+	ServiceAccountKey     string `json:"service_account_key"`      // as base64
+	ServiceAccountKeyFile string `json:"service_account_key_file"` // alternatively, as JSON in a file (watched for changes)
+
+	Timeout           string      `json:"timeout"` // e.g. "10s"
+	MaxRetries        json.Number `json:"max_retries"`
+	EncryptionKeyFile string      `json:"encryption_key_file"` // for client-side encryption; see envelope.LoadKeyFile
+
+	// UnencryptedDocuments says what becomes of documents read which aren't encrypted, given an encryption_key_file:
+	// "read" (the default) reads them as they are, while "reject" refuses them (see envelope.Strict).
+	UnencryptedDocuments string `json:"unencrypted_documents"`
+
+	// Documents read may be cached, either in memory up to the size (in bytes) or on disk within the directory,
+	// such that each is only read again when it has changed. See CacheReads.
+	ReadCacheSize      json.Number `json:"read_cache_size"`
+	ReadCacheDirectory string      `json:"read_cache_directory"`
+}
+
+// LoadConfig reads the JSON file, if named, and then applies any environment variables beneath the prefix,
+// which take precedence. The configuration is only validated when it's built. YAML files aren't read, as that
+// would have the module depend upon a YAML parser.
+func LoadConfig(filename, environmentPrefix string) (Config, error) {
+	var config Config
+
+	if len(filename) > 0 {
+		raw, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return config, err
+		}
+
+		decoder := json.NewDecoder(strings.NewReader(string(raw)))
+		decoder.DisallowUnknownFields()
+		if err = decoder.Decode(&config); err != nil {
+			return config, fmt.Errorf("malformed storage configuration file '%s': %s", filename, err)
+		}
+	}
+
+	return config.overlay(environmentPrefix, os.LookupEnv), nil
+}
+func (this Config) overlay(prefix string, lookup func(string) (string, bool)) Config {
+	for name, field := range this.fields() {
+		if value, found := lookup(prefix + strings.ToUpper(name)); found {
+			*field = value
+		}
+	}
+
+	if value, found := lookup(prefix + "MAX_RETRIES"); found {
+		this.MaxRetries = json.Number(value)
+	}
+	if value, found := lookup(prefix + "READ_CACHE_SIZE"); found {
+		this.ReadCacheSize = json.Number(value)
+	}
+
+	return this
+}
+func (this *Config) fields() map[string]*string {
+	return map[string]*string{
+		"engine":                    &this.Engine,
+		"credentials":               &this.Credentials,
+		"profile":                   &this.Profile,
+		"shared_credentials_file":   &this.SharedCredentialsFile,
+		"credentials_endpoint":      &this.CredentialsEndpoint,
+		"credentials_authorization": &this.CredentialsAuthorization,
+		"session_token":             &this.SessionToken,
+		"path_prefix":               &this.PathPrefix,
+		"namespace":                 &this.Namespace,
+		"address":                   &this.Address,
+		"access_key":                &this.AccessKey,
+		"secret_key":                &this.SecretKey,
+		"server_side_encryption":    &this.ServerSideEncryption,
+		"kms_key_id":                &this.KMSKeyID,
+		"bucket":                    &this.Bucket,
+		"service_account_key":       &this.ServiceAccountKey,
+		"service_account_key_file":  &this.ServiceAccountKeyFile,
+		"timeout":                   &this.Timeout,
+		"encryption_key_file":       &this.EncryptionKeyFile,
+		"unencrypted_documents":     &this.UnencryptedDocuments,
+		"read_cache_directory":      &this.ReadCacheDirectory,
+	}
+}
+
+// Wireup validates the configuration, reporting every problem found, and gives the Wireup it declares,
+// to which further options (e.g. key mapping or history) may be given.
+func (this Config) Wireup(options ...Option) (*Wireup, error) {
+	declared, err := this.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(append(declared, options...)...), nil
+}
+
+// Options validates the configuration, reporting every problem found, and gives the options it declares.
+func (this Config) Options() ([]Option, error) {
+	validation := &validation{}
+	options := []Option{PathPrefix(this.PathPrefix), Namespace(this.Namespace)}
+
+	switch strings.ToLower(strings.TrimSpace(this.Engine)) {
+	case "s3":
+		options = append(options, this.s3(validation)...)
+	case "gcs":
+		options = append(options, this.gcs(validation)...)
+	case "":
+		validation.missing("engine")
+	default:
+		validation.add("engine", fmt.Errorf("unknown storage engine '%s' (expected 's3' or 'gcs')", this.Engine))
+	}
+
+	if len(this.Timeout) > 0 {
+		if timeout, err := time.ParseDuration(this.Timeout); err != nil || timeout <= 0 {
+			validation.add("timeout", fmt.Errorf("malformed duration '%s'", this.Timeout))
+		} else {
+			options = append(options, TimeoutAfter(timeout))
+		}
+	}
+
+	if len(this.MaxRetries) > 0 {
+		if retries, err := strconv.ParseUint(this.MaxRetries.String(), 10, 64); err != nil {
+			validation.add("max_retries", fmt.Errorf("malformed count '%s'", this.MaxRetries))
+		} else {
+			options = append(options, MaxRetries(retries))
+		}
+	}
+
+	var encryption []envelope.Option
+	switch strings.ToLower(strings.TrimSpace(this.UnencryptedDocuments)) {
+	case "", "read":
+	case "reject":
+		encryption = append(encryption, envelope.Strict())
+	default:
+		validation.add("unencrypted_documents", fmt.Errorf("unknown mode '%s' (expected 'read' or 'reject')", this.UnencryptedDocuments))
+	}
+
+	if len(this.EncryptionKeyFile) > 0 {
+		if keys, err := envelope.LoadKeyFile(this.EncryptionKeyFile); err != nil {
+			validation.add("encryption_key_file", err)
+		} else {
+			options = append(options, EncryptWith(keys, encryption...))
+		}
+	} else if len(encryption) > 0 {
+		validation.add("unencrypted_documents", errors.New("not applicable without an encryption_key_file"))
+	}
+
+	switch directory := strings.TrimSpace(this.ReadCacheDirectory); {
+	case len(directory) > 0 && len(this.ReadCacheSize) > 0:
+		validation.add("read_cache_size", errors.New("not applicable to a read_cache_directory"))
+	case len(directory) > 0:
+		options = append(options, CacheReads(readcache.NewDisk(directory)))
+	case len(this.ReadCacheSize) > 0:
+		if size, err := strconv.ParseUint(this.ReadCacheSize.String(), 10, 31); err != nil || size == 0 {
+			validation.add("read_cache_size", fmt.Errorf("malformed size '%s'", this.ReadCacheSize))
+		} else {
+			options = append(options, CacheReads(readcache.NewMemory(int(size))))
+		}
+	}
+
+	return options, validation.err()
+}
+func (this Config) s3(validation *validation) (options []Option) {
+	address, err := url.Parse(strings.TrimSpace(this.Address))
+	if len(strings.TrimSpace(this.Address)) == 0 {
+		validation.missing("address")
+	} else if err != nil || len(address.Scheme) == 0 || len(address.Host) == 0 {
+		validation.add("address", fmt.Errorf("malformed storage address '%s'", this.Address))
+	}
+	options = append(options, S3(address, this.AccessKey, this.SecretKey))
+
+	switch strings.ToLower(strings.TrimSpace(this.Credentials)) {
+	case "":
+		if len(strings.TrimSpace(this.AccessKey)) == 0 {
+			validation.missing("access_key")
+		}
+		if len(strings.TrimSpace(this.SecretKey)) == 0 {
+			validation.missing("secret_key")
+		}
+		options = append(options, SessionToken(this.SessionToken))
+	case "environment":
+		options = append(options, provide(validation, credentials.Environment(), validAccessKeys))
+	case "shared_file":
+		options = append(options, provide(validation, credentials.NewSharedCredentialsFile(this.SharedCredentialsFile, this.Profile), validAccessKeys))
+	case "endpoint":
+		options = append(options, provide(validation, this.endpoint(), validAccessKeys))
+	default:
+		validation.add("credentials", fmt.Errorf("unknown source '%s' (expected 'environment', 'shared_file', or 'endpoint')", this.Credentials))
+	}
+
+	switch strings.ToLower(strings.TrimSpace(this.ServerSideEncryption)) {
+	case "", "aes256":
+		options = append(options, ServerSideEncryptionAES256())
+	case "aws:kms":
+		if len(strings.TrimSpace(this.KMSKeyID)) == 0 {
+			validation.missing("kms_key_id")
+		}
+		options = append(options, ServerSideEncryptionKMS(this.KMSKeyID))
+	case "none":
+		options = append(options, WithoutServerSideEncryption())
+	default:
+		validation.add("server_side_encryption", fmt.Errorf("unknown mode '%s' (expected 'AES256', 'aws:kms', or 'none')", this.ServerSideEncryption))
+	}
+
+	return options
+}
+func (this Config) gcs(validation *validation) []Option {
+	if len(strings.TrimSpace(this.Bucket)) == 0 {
+		validation.missing("bucket")
+	}
+
+	var key []byte
+	var err error
+	var options []Option
+	switch source := strings.ToLower(strings.TrimSpace(this.Credentials)); {
+	case source == "environment":
+		options = append(options, provide(validation, credentials.Environment(), validServiceAccountKey))
+	case len(source) > 0:
+		validation.add("credentials", fmt.Errorf("unknown source '%s' (expected 'environment')", this.Credentials))
+	case len(strings.TrimSpace(this.ServiceAccountKey)) > 0:
+		if key, err = base64.StdEncoding.DecodeString(strings.TrimSpace(this.ServiceAccountKey)); err != nil {
+			validation.add("service_account_key", fmt.Errorf("malformed base64: %s", err))
+		} else if _, err = gcs.ParseCredentialsFromJSON(key); err != nil {
+			validation.add("service_account_key", fmt.Errorf("malformed service account key: %s", err))
+		}
+	case len(this.ServiceAccountKeyFile) > 0:
+		options = append(options, provide(validation, credentials.NewServiceAccountFile(this.ServiceAccountKeyFile), validServiceAccountKey))
+	default:
+		validation.missing("service_account_key")
+	}
+
+	return append([]Option{GoogleCloudStorage(context.Background(), this.Bucket, this.PathPrefix, key)}, options...)
+}
+
+func (this Config) endpoint() persist.CredentialsProvider {
+	if len(strings.TrimSpace(this.CredentialsEndpoint)) == 0 {
+		return credentials.ContainerEndpoint()
+	}
+
+	client := &http.Client{Timeout: time.Second * 5}
+	return credentials.NewEndpoint(strings.TrimSpace(this.CredentialsEndpoint), this.CredentialsAuthorization, client, time.Now)
+}
+
+// provide resolves the credentials once, such that a misconfigured provider is reported before it's used.
+func provide(validation *validation, provider persist.CredentialsProvider, check func(persist.Credentials) error) Option {
+	if provided, err := provider.Credentials(); err != nil {
+		validation.add("credentials", err)
+	} else if err = check(provided); err != nil {
+		validation.add("credentials", err)
+	}
+
+	return CredentialsFrom(provider)
+}
+func validAccessKeys(provided persist.Credentials) error {
+	if len(provided.AccessKey) == 0 || len(provided.SecretKey) == 0 {
+		return credentials.ErrIncompleteCredentials
+	}
+	return nil
+}
+func validServiceAccountKey(provided persist.Credentials) error {
+	if len(provided.ServiceAccountKey) == 0 {
+		return errors.New("no service account key provided")
+	} else if _, err := gcs.ParseCredentialsFromJSON(provided.ServiceAccountKey); err != nil {
+		return fmt.Errorf("malformed service account key: %s", err)
+	}
+	return nil
+}
+
+type validation struct{ problems []string }
+
+func (this *validation) missing(field string) {
+	this.add(field, errors.New("missing"))
+}
+func (this *validation) add(field string, err error) {
+	this.problems = append(this.problems, field+": "+err.Error())
+}
+func (this *validation) err() error {
+	if len(this.problems) == 0 {
+		return nil
+	}
+
+	return errors.New("invalid storage configuration: " + strings.Join(this.problems, "; "))
+}
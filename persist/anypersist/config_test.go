@@ -0,0 +1,366 @@
+package anypersist
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector"
+)
+
+func TestConfigFixture(t *testing.T) {
+	gunit.Run(new(ConfigFixture), t)
+}
+
+type ConfigFixture struct {
+	*gunit.Fixture
+
+	directory string
+}
+
+func (this *ConfigFixture) Setup() {
+	this.directory, _ = ioutil.TempDir("", "anypersist")
+}
+func (this *ConfigFixture) Teardown() {
+	_ = os.RemoveAll(this.directory)
+}
+
+func (this *ConfigFixture) write(name, contents string) string {
+	filename := filepath.Join(this.directory, name)
+	_ = ioutil.WriteFile(filename, []byte(contents), 0600)
+	return filename
+}
+
+func (this *ConfigFixture) TestFileLoaded() {
+	filename := this.write("storage.json", `{
+		"engine": "s3",
+		"address": "https://bucket.s3-us-west-1.amazonaws.com/",
+		"access_key": "access",
+		"secret_key": "secret",
+		"path_prefix": "/prefix",
+		"timeout": "5s",
+		"max_retries": 3
+	}`)
+
+	config, err := LoadConfig(filename, "PROJECTOR_TEST_UNUSED_")
+
+	this.So(err, should.BeNil)
+	this.So(config, should.Resemble, Config{
+		Engine:     "s3",
+		Address:    "https://bucket.s3-us-west-1.amazonaws.com/",
+		AccessKey:  "access",
+		SecretKey:  "secret",
+		PathPrefix: "/prefix",
+		Timeout:    "5s",
+		MaxRetries: json.Number("3"),
+	})
+}
+
+func (this *ConfigFixture) TestMalformedFileRejected() {
+	_, err := LoadConfig(this.write("storage.json", `{"engine": "s3",`), "")
+
+	this.So(err, should.NotBeNil)
+}
+
+func (this *ConfigFixture) TestUnknownFieldInFileRejected() {
+	_, err := LoadConfig(this.write("storage.json", `{"engnie": "s3"}`), "")
+
+	this.So(err, should.NotBeNil)
+}
+
+func (this *ConfigFixture) TestMissingFileRejected() {
+	_, err := LoadConfig(filepath.Join(this.directory, "missing.json"), "")
+
+	this.So(err, should.NotBeNil)
+}
+
+func (this *ConfigFixture) TestEnvironmentTakesPrecedence() {
+	environment := map[string]string{
+		"PROJECTOR_ENGINE":      "gcs",
+		"PROJECTOR_BUCKET":      "bucket",
+		"PROJECTOR_MAX_RETRIES": "7",
+		"OTHER_NAMESPACE":       "ignored",
+	}
+	lookup := func(name string) (string, bool) { value, found := environment[name]; return value, found }
+
+	config := Config{Engine: "s3", PathPrefix: "/prefix"}.overlay("PROJECTOR_", lookup)
+
+	this.So(config, should.Resemble, Config{
+		Engine:     "gcs",
+		Bucket:     "bucket",
+		PathPrefix: "/prefix",
+		MaxRetries: json.Number("7"),
+	})
+}
+
+func (this *ConfigFixture) TestValidS3ConfigurationBuilds() {
+	config := Config{
+		Engine:               "S3",
+		Address:              "https://bucket.s3-us-west-1.amazonaws.com/",
+		AccessKey:            "access",
+		SecretKey:            "secret",
+		ServerSideEncryption: "aws:kms",
+		KMSKeyID:             "key",
+		Timeout:              "5s",
+		MaxRetries:           "3",
+	}
+
+	wireup, err := config.Wireup()
+	this.So(err, should.BeNil)
+
+	storage, err := wireup.Build()
+	this.So(err, should.BeNil)
+	this.So(storage, should.NotBeNil)
+}
+
+func (this *ConfigFixture) TestValidGCSConfigurationBuilds() {
+	config := Config{
+		Engine:            "gcs",
+		Bucket:            "bucket",
+		ServiceAccountKey: base64.StdEncoding.EncodeToString(serviceAccountKey()),
+	}
+
+	wireup, err := config.Wireup()
+	this.So(err, should.BeNil)
+
+	storage, err := wireup.Build()
+	this.So(err, should.BeNil)
+	this.So(storage, should.NotBeNil)
+}
+
+func (this *ConfigFixture) TestServiceAccountKeyReadFromFile() {
+	config := Config{
+		Engine:                "gcs",
+		Bucket:                "bucket",
+		ServiceAccountKeyFile: this.write("key.json", string(serviceAccountKey())),
+	}
+
+	_, err := config.Options()
+
+	this.So(err, should.BeNil)
+}
+
+func (this *ConfigFixture) TestSharedCredentialsFileResolved() {
+	config := Config{
+		Engine:                "s3",
+		Address:               "https://bucket.s3-us-west-1.amazonaws.com/",
+		Credentials:           "shared_file",
+		Profile:               "deploy",
+		SharedCredentialsFile: this.write("credentials", "[deploy]\naws_access_key_id = a\naws_secret_access_key = s\n"),
+	}
+
+	wireup, err := config.Wireup()
+	this.So(err, should.BeNil)
+
+	_, err = wireup.Build()
+	this.So(err, should.BeNil)
+}
+
+func (this *ConfigFixture) TestUnresolvableCredentialsReported() {
+	config := Config{
+		Engine:                "s3",
+		Address:               "https://bucket.s3-us-west-1.amazonaws.com/",
+		Credentials:           "shared_file",
+		Profile:               "missing",
+		SharedCredentialsFile: this.write("credentials", "[deploy]\naws_access_key_id = a\naws_secret_access_key = s\n"),
+	}
+
+	_, err := config.Options()
+
+	this.So(err, should.NotBeNil)
+	this.So(err.Error(), should.StartWith, "invalid storage configuration: credentials: ")
+}
+
+func (this *ConfigFixture) TestTemporaryCredentialsFromEndpoint() {
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		this.So(request.Header.Get("Authorization"), should.Equal, "authorization")
+		_, _ = response.Write([]byte(`{"AccessKeyId": "a", "SecretAccessKey": "s", "Token": "t", "Expiration": "2100-01-01T00:00:00Z"}`))
+	}))
+	defer server.Close()
+	config := Config{
+		Engine:                   "s3",
+		Address:                  "https://bucket.s3-us-west-1.amazonaws.com/",
+		Credentials:              "endpoint",
+		CredentialsEndpoint:      server.URL,
+		CredentialsAuthorization: "authorization",
+	}
+
+	wireup, err := config.Wireup()
+	this.So(err, should.BeNil)
+
+	_, err = wireup.Build()
+	this.So(err, should.BeNil)
+}
+
+func (this *ConfigFixture) TestUnknownCredentialsSourceRejected() {
+	_, err := Config{Engine: "gcs", Bucket: "bucket", Credentials: "vault"}.Options()
+
+	this.So(err.Error(), should.Equal, "invalid storage configuration: credentials: unknown source 'vault' (expected 'environment')")
+}
+
+func (this *ConfigFixture) TestMissingEngineRejected() {
+	_, err := Config{}.Options()
+
+	this.So(err.Error(), should.Equal, "invalid storage configuration: engine: missing")
+}
+
+func (this *ConfigFixture) TestUnknownEngineRejected() {
+	_, err := Config{Engine: "azure"}.Options()
+
+	this.So(err.Error(), should.Equal, "invalid storage configuration: engine: unknown storage engine 'azure' (expected 's3' or 'gcs')")
+}
+
+func (this *ConfigFixture) TestEveryMissingS3FieldReported() {
+	_, err := Config{Engine: "s3", ServerSideEncryption: "aws:kms"}.Options()
+
+	this.So(err.Error(), should.Equal, "invalid storage configuration: "+
+		"address: missing; access_key: missing; secret_key: missing; kms_key_id: missing")
+}
+
+func (this *ConfigFixture) TestMalformedS3FieldsReported() {
+	config := Config{
+		Engine:               "s3",
+		Address:              "bucket",
+		AccessKey:            "access",
+		SecretKey:            "secret",
+		ServerSideEncryption: "rot13",
+		Timeout:              "soon",
+		MaxRetries:           "-1",
+	}
+
+	_, err := config.Options()
+
+	this.So(err.Error(), should.Equal, "invalid storage configuration: "+
+		"address: malformed storage address 'bucket'; "+
+		"server_side_encryption: unknown mode 'rot13' (expected 'AES256', 'aws:kms', or 'none'); "+
+		"timeout: malformed duration 'soon'; "+
+		"max_retries: malformed count '-1'")
+}
+
+func (this *ConfigFixture) TestMissingGCSFieldsReported() {
+	_, err := Config{Engine: "gcs"}.Options()
+
+	this.So(err.Error(), should.Equal, "invalid storage configuration: bucket: missing; service_account_key: missing")
+}
+
+func (this *ConfigFixture) TestMalformedServiceAccountKeyReported() {
+	_, err := Config{Engine: "gcs", Bucket: "bucket", ServiceAccountKey: "not base64!"}.Options()
+
+	this.So(err, should.NotBeNil)
+	this.So(err.Error(), should.StartWith, "invalid storage configuration: service_account_key: malformed base64")
+}
+
+func (this *ConfigFixture) TestUnparsableServiceAccountKeyReported() {
+	encoded := base64.StdEncoding.EncodeToString([]byte(`{"client_email": "a@b.c", "private_key": "nope"}`))
+
+	_, err := Config{Engine: "gcs", Bucket: "bucket", ServiceAccountKey: encoded}.Options()
+
+	this.So(err, should.NotBeNil)
+	this.So(err.Error(), should.StartWith, "invalid storage configuration: service_account_key: malformed service account key")
+}
+
+func (this *ConfigFixture) TestMissingEncryptionKeyFileReported() {
+	config := Config{
+		Engine:            "s3",
+		Address:           "https://bucket.s3-us-west-1.amazonaws.com/",
+		AccessKey:         "access",
+		SecretKey:         "secret",
+		EncryptionKeyFile: filepath.Join(this.directory, "missing.json"),
+	}
+
+	_, err := config.Options()
+
+	this.So(err, should.NotBeNil)
+	this.So(err.Error(), should.StartWith, "invalid storage configuration: encryption_key_file: ")
+}
+
+func (this *ConfigFixture) TestUnencryptedDocumentsModeReported() {
+	config := Config{Engine: "gcs", Bucket: "bucket", ServiceAccountKey: base64.StdEncoding.EncodeToString(serviceAccountKey())}
+
+	config.UnencryptedDocuments = "reject"
+	_, err := config.Options()
+	this.So(err.Error(), should.Equal, "invalid storage configuration: unencrypted_documents: not applicable without an encryption_key_file")
+
+	config.UnencryptedDocuments = "ignore"
+	_, err = config.Options()
+	this.So(err.Error(), should.Equal, "invalid storage configuration: unencrypted_documents: unknown mode 'ignore' (expected 'read' or 'reject')")
+}
+
+func (this *ConfigFixture) TestReadCacheConfigured() {
+	config := Config{Engine: "gcs", Bucket: "bucket", ServiceAccountKey: base64.StdEncoding.EncodeToString(serviceAccountKey())}
+
+	config.ReadCacheSize = "1048576"
+	wireup, err := config.Wireup()
+	this.So(err, should.BeNil)
+	this.So(wireup.readCache, should.NotBeNil)
+
+	config.ReadCacheSize, config.ReadCacheDirectory = "", this.directory
+	wireup, err = config.Wireup()
+	this.So(err, should.BeNil)
+	this.So(wireup.readCache, should.NotBeNil)
+}
+
+func (this *ConfigFixture) TestMalformedReadCacheReported() {
+	config := Config{Engine: "gcs", Bucket: "bucket", ServiceAccountKey: base64.StdEncoding.EncodeToString(serviceAccountKey())}
+
+	config.ReadCacheSize = "lots"
+	_, err := config.Options()
+	this.So(err.Error(), should.Equal, "invalid storage configuration: read_cache_size: malformed size 'lots'")
+
+	config.ReadCacheDirectory = this.directory
+	_, err = config.Options()
+	this.So(err.Error(), should.Equal, "invalid storage configuration: read_cache_size: not applicable to a read_cache_directory")
+}
+
+func (this *ConfigFixture) TestHistoryRequiresListableStorage() {
+	wireup := New(KeepHistory(""))
+
+	storage, err := wireup.decorate(&UnlistableStorage{})
+
+	this.So(storage, should.BeNil)
+	this.So(err.Error(), should.Equal, "storage [unlistable] is unable to list documents, which keeping their history requires")
+	this.So(wireup.err, should.Equal, err)
+}
+
+func (this *ConfigFixture) TestChooseRejectsUnknownEngine() {
+	_, err := New(Choose("azure", nil, "", "", context.Background(), "", "", "")).Build()
+
+	this.So(err, should.NotBeNil)
+}
+
+func (this *ConfigFixture) TestChooseRejectsMalformedServiceAccountKey() {
+	_, err := New(Choose("gcs", nil, "", "", context.Background(), "bucket", "", "not base64!")).Build()
+
+	this.So(err, should.NotBeNil)
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+type UnlistableStorage struct{}
+
+func (this *UnlistableStorage) Name() string                   { return "unlistable" }
+func (this *UnlistableStorage) Read(projector.Document) error  { return nil }
+func (this *UnlistableStorage) ReadPanic(projector.Document)   {}
+func (this *UnlistableStorage) Write(projector.Document) error { return nil }
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+func serviceAccountKey() []byte {
+	key, _ := rsa.GenerateKey(rand.Reader, 1024)
+	encoded, _ := x509.MarshalPKCS8PrivateKey(key)
+	private := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: encoded})
+	raw, _ := json.Marshal(map[string]string{"client_email": "projector@example.com", "private_key": string(private)})
+	return raw
+}
@@ -3,10 +3,17 @@ package anypersist
 import (
 	"context"
 	"encoding/base64"
+	"fmt"
 	"math"
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/smartystreets/projector/persist"
+	"github.com/smartystreets/projector/persist/envelope"
+	"github.com/smartystreets/projector/persist/historypersist"
+	"github.com/smartystreets/projector/persist/s3persist"
+	"github.com/smartystreets/projector/persist/shadowpersist"
 )
 
 type Option func(*Wireup)
@@ -15,6 +22,7 @@ func Defaults() Option {
 	return func(this *Wireup) {
 		TimeoutAfter(time.Second * 10)(this)
 		MaxRetries(math.MaxUint32)(this)
+		ServerSideEncryptionAES256()(this)
 	}
 }
 func TimeoutAfter(httpTimeout time.Duration) Option {
@@ -24,16 +32,144 @@ func MaxRetries(max uint64) Option {
 	return func(this *Wireup) { this.maxRetries = max }
 }
 
+// PathPrefix places every document beneath the prefix within the bucket.
+func PathPrefix(value string) Option {
+	return func(this *Wireup) { this.pathPrefix = strings.TrimSpace(value) }
+}
+
+// Namespace places every document beneath the namespace within the path prefix, whichever the engine
+// (see s3persist.Namespace).
+func Namespace(value string) Option {
+	return func(this *Wireup) { this.namespace = strings.TrimSpace(value) }
+}
+
+// MapKeys stores each document at the key given by the mapper (within any prefix and namespace) rather than at
+// its path.
+func MapKeys(mapper persist.KeyMapper) Option {
+	return func(this *Wireup) { this.keys = mapper }
+}
+
+// SessionToken accompanies temporary access keys for S3 (e.g. those issued by AWS STS) given with the engine.
+// Because such keys expire, providing them with CredentialsFrom is usually more suitable.
+func SessionToken(token string) Option {
+	return func(this *Wireup) { this.awsSessionToken = strings.TrimSpace(token) }
+}
+
+// CredentialsFrom resolves the credentials from the provider as each request is made, in place of any
+// access keys or service account key given with the engine, such that they may be rotated without a restart.
+func CredentialsFrom(provider persist.CredentialsProvider) Option {
+	return func(this *Wireup) { this.credentials = provider }
+}
+
+// MirrorTo repeats every write (and removal) to the storage built with the secondary storages, on a best-effort
+// basis, such as when running alongside another backend during a migration. See tieredpersist.Mirror.
+func MirrorTo(secondaries ...persist.ReadWriter) Option {
+	return func(this *Wireup) { this.mirrors = append(this.mirrors, secondaries...) }
+}
+
+// FallBackTo reads documents from the secondary storage which the storage built doesn't have,
+// such as when migrating from the secondary storage. See tieredpersist.Fallback.
+func FallBackTo(secondary persist.ReadWriter) Option {
+	return func(this *Wireup) { this.fallback = secondary }
+}
+
+// CacheIn reads documents through the cache storage, which should be faster than the storage built.
+// See tieredpersist.Cache.
+func CacheIn(cache persist.ReadWriter) Option {
+	return func(this *Wireup) { this.cache = cache }
+}
+
+// CacheReads keeps the documents read and written by the storage engine in the cache, such that each document
+// is only read again (with a conditional GET) when it has changed. See readcache.NewMemory and readcache.NewDisk.
+func CacheReads(cache persist.ReadCache) Option {
+	return func(this *Wireup) { this.readCache = cache }
+}
+
+// KeepHistory records an immutable copy of every document written beneath the prefix (by default "/history"),
+// such that the storage built is a *historypersist.ReadWriter which can read documents as they were.
+func KeepHistory(prefix string) Option {
+	return func(this *Wireup) {
+		this.history = true
+		this.historyPath = strings.TrimSpace(prefix)
+		if len(this.historyPath) == 0 {
+			this.historyPath = historypersist.DefaultPrefix
+		}
+	}
+}
+
+// FollowAlias reads and writes documents within the generation named by the alias document at the path.
+func FollowAlias(path string) Option {
+	return func(this *Wireup) { this.alias = strings.TrimSpace(path) }
+}
+
+// ShadowWrites reads documents from the storage built but writes them beneath the prefix instead, recording
+// how they differ from the documents read when a recorder is given. It suits trying out new projection logic.
+func ShadowWrites(prefix string, recorder shadowpersist.Recorder) Option {
+	return func(this *Wireup) {
+		this.shadowed, this.shadowPrefix, this.recorder = true, strings.TrimSpace(prefix), recorder
+	}
+}
+
+// ShadowWritesTo reads documents from the storage built but writes them to the shadow storage instead.
+func ShadowWritesTo(shadow persist.ReadWriter, recorder shadowpersist.Recorder) Option {
+	return func(this *Wireup) { this.shadowed, this.shadow, this.recorder = true, shadow, recorder }
+}
+
+// DiscardWrites reads documents from the storage built but never writes them, only recording how they differ.
+func DiscardWrites(recorder shadowpersist.Recorder) Option {
+	return func(this *Wireup) { this.shadowed, this.recorder = true, recorder }
+}
+
+// EncryptWith seals every document on the client using AES-GCM envelope encryption
+// under the current key of the provider, regardless of the storage engine chosen.
+func EncryptWith(keys envelope.KeyProvider, options ...envelope.Option) Option {
+	return func(this *Wireup) { this.cipher = envelope.NewCipher(keys, options...) }
+}
+
+// ServerSideEncryptionAES256 has S3 encrypt documents at rest with keys it manages (SSE-S3).
+func ServerSideEncryptionAES256() Option {
+	return func(this *Wireup) { this.s3encryption = s3persist.EncryptionAES256 }
+}
+
+// ServerSideEncryptionKMS has S3 encrypt documents at rest using the specified AWS KMS key (SSE-KMS).
+func ServerSideEncryptionKMS(keyID string) Option {
+	return func(this *Wireup) { this.s3encryption = s3persist.EncryptionKMS(strings.TrimSpace(keyID)) }
+}
+
+// ServerSideEncryptionCustomerKey has S3 encrypt documents at rest using the provided 256-bit key (SSE-C).
+func ServerSideEncryptionCustomerKey(key []byte) Option {
+	return func(this *Wireup) { this.s3encryption = s3persist.EncryptionCustomerKey(key) }
+}
+
+// WithoutServerSideEncryption omits the S3 server-side encryption headers (see s3persist.EncryptionNone).
+func WithoutServerSideEncryption() Option {
+	return func(this *Wireup) { this.s3encryption = s3persist.EncryptionNone }
+}
+
+// Choose selects the engine by name, "gcs" or "s3" (the default when blank), with the service account key encoded
+// as base64. An unknown engine or a malformed key is reported when the storage is built.
 func Choose(engine string, address *url.URL, accessKey, secretKey string,
 	ctx context.Context, bucketName, pathPrefix, serviceAccountKey string,
 ) Option {
-	if engine == "gcs" {
-		raw, _ := base64.StdEncoding.DecodeString(serviceAccountKey)
+	switch strings.ToLower(strings.TrimSpace(engine)) {
+	case "gcs":
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(serviceAccountKey))
+		if err != nil {
+			return failure(fmt.Errorf("malformed service account key for Google Cloud Storage (expected base64): %s", err))
+		}
 		return GoogleCloudStorage(ctx, bucketName, pathPrefix, raw)
-	} else {
-		return S3(address, accessKey, secretKey)
+	case "s3", "":
+		return func(this *Wireup) {
+			S3(address, accessKey, secretKey)(this)
+			PathPrefix(pathPrefix)(this)
+		}
+	default:
+		return failure(fmt.Errorf("unknown storage engine '%s' (expected 's3' or 'gcs')", engine))
 	}
 }
+func failure(err error) Option {
+	return func(this *Wireup) { this.err = err }
+}
 func S3(address *url.URL, accessKey, secretKey string) Option {
 	return func(this *Wireup) {
 		this.engine = engineS3
@@ -3,24 +3,48 @@ package anypersist
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"time"
 
 	"github.com/smartystreets/gcs"
 	"github.com/smartystreets/projector/persist"
+	"github.com/smartystreets/projector/persist/aliaspersist"
+	"github.com/smartystreets/projector/persist/credentials"
 	"github.com/smartystreets/projector/persist/gcspersist"
+	"github.com/smartystreets/projector/persist/historypersist"
 	"github.com/smartystreets/projector/persist/s3persist"
+	"github.com/smartystreets/projector/persist/shadowpersist"
+	"github.com/smartystreets/projector/persist/tieredpersist"
 )
 
 type Wireup struct {
 	engine int
-
-	s3address    *url.URL
-	awsAccessKey string
-	awsSecretKey string
-	timeout      time.Duration
-	maxRetries   uint64
+	err    error
+
+	s3address       *url.URL
+	awsAccessKey    string
+	awsSecretKey    string
+	awsSessionToken string
+	timeout         time.Duration
+	maxRetries      uint64
+	cipher          persist.Cipher
+	s3encryption    s3persist.Encryption
+	namespace       string
+	keys            persist.KeyMapper
+	credentials     persist.CredentialsProvider
+	mirrors         []persist.ReadWriter
+	fallback        persist.ReadWriter
+	cache           persist.ReadWriter
+	readCache       persist.ReadCache
+	history         bool
+	historyPath     string
+	alias           string
+	shadowed        bool
+	shadow          persist.ReadWriter
+	shadowPrefix    string
+	recorder        shadowpersist.Recorder
 
 	context           context.Context
 	bucketName        string
@@ -38,6 +62,18 @@ func New(options ...Option) *Wireup {
 }
 
 func (this *Wireup) Build() (persist.ReadWriter, error) {
+	if this.err != nil {
+		return nil, this.err
+	}
+
+	engine, err := this.buildEngine()
+	if err != nil {
+		return nil, err
+	}
+
+	return this.decorate(engine)
+}
+func (this *Wireup) buildEngine() (persist.ReadWriter, error) {
 	switch this.engine {
 	case engineS3:
 		return this.buildS3()
@@ -47,33 +83,81 @@ func (this *Wireup) Build() (persist.ReadWriter, error) {
 		return nil, errors.New("storage engine to build not specified")
 	}
 }
+func (this *Wireup) decorate(engine persist.ReadWriter) (persist.ReadWriter, error) {
+	if len(this.mirrors) > 0 {
+		engine = tieredpersist.NewMirror(engine, this.mirrors...)
+	}
+	if this.fallback != nil {
+		engine = tieredpersist.NewFallback(engine, this.fallback)
+	}
+	if this.cache != nil {
+		engine = tieredpersist.NewCache(this.cache, engine)
+	}
+	if this.history {
+		storage, ok := engine.(historypersist.Storage)
+		if !ok {
+			this.err = fmt.Errorf("storage [%s] is unable to list documents, which keeping their history requires", engine.Name())
+			return nil, this.err
+		}
+		engine = historypersist.NewReadWriter(storage, utcNow).WithPrefix(this.historyPath)
+	}
+	if len(this.alias) > 0 {
+		engine = aliaspersist.NewReadWriter(engine, this.alias, utcNow)
+	}
+	if this.shadowed {
+		engine = shadowpersist.NewReadWriter(engine).WithShadow(this.shadow).WithPrefix(this.shadowPrefix).WithRecorder(this.recorder)
+	}
+
+	return engine, nil
+}
 
 func (this *Wireup) buildS3() (persist.ReadWriter, error) {
 	if this.s3address == nil {
 		return nil, errors.New("no storage address specified for S3")
-	} else if len(this.awsAccessKey) == 0 {
+	} else if this.credentials == nil && len(this.awsAccessKey) == 0 {
 		return nil, errors.New("credentials for S3 not provided: AWS Access Key")
-	} else if len(this.awsSecretKey) == 0 {
+	} else if this.credentials == nil && len(this.awsSecretKey) == 0 {
 		return nil, errors.New("credentials for S3 not provided: AWS Secret Key")
+	} else if err := this.s3encryption.Validate(); err != nil {
+		return nil, err
 	}
 
 	var httpClient persist.HTTPClient
 	httpClient = this.buildHTTPClient()
 	httpClient = this.appendRetryClient(httpClient)
-	engine := s3persist.NewStorage(this.s3address, this.awsAccessKey, this.awsSecretKey, httpClient)
+	options := []s3persist.Option{
+		s3persist.EncryptWith(this.cipher),
+		s3persist.ServerSideEncryption(this.s3encryption),
+		s3persist.PathPrefix(this.pathPrefix),
+		s3persist.Namespace(this.namespace),
+		s3persist.MapKeys(this.keys),
+		s3persist.CacheReads(this.readCache),
+	}
+	if this.credentials != nil {
+		options = append(options, s3persist.Credentials(this.credentials))
+	} else if len(this.awsSessionToken) > 0 {
+		options = append(options, s3persist.Credentials(credentials.Static(persist.Credentials{
+			AccessKey: this.awsAccessKey, SecretKey: this.awsSecretKey, SessionToken: this.awsSessionToken,
+		})))
+	}
+	engine := s3persist.NewStorage(this.s3address, this.awsAccessKey, this.awsSecretKey, httpClient, options...)
 
 	return engine, nil
 }
 func (this *Wireup) buildGCS() (persist.ReadWriter, error) {
 	if len(this.bucketName) == 0 {
 		return nil, errors.New("no target bucket specified for Google Cloud Storage")
-	} else if len(this.serviceAccountKey) == 0 {
+	} else if this.credentials == nil && len(this.serviceAccountKey) == 0 {
 		return nil, errors.New("credentials for Google Cloud Storage not provided: Service Account Key")
 	}
 
-	credentials, err := gcs.ParseCredentialsFromJSON(this.serviceAccountKey)
-	if err != nil {
-		return nil, err
+	var credentials gcs.Credentials
+	if this.credentials == nil {
+		parsed, err := gcs.ParseCredentialsFromJSON(this.serviceAccountKey)
+		if err != nil {
+			return nil, err
+		}
+		credentials = parsed
 	}
 
 	return gcspersist.NewReadWriter(func() gcspersist.StorageSettings {
@@ -81,8 +165,14 @@ func (this *Wireup) buildGCS() (persist.ReadWriter, error) {
 			HTTPClient:  this.appendRetryClient(this.buildHTTPClient()),
 			BucketName:  this.bucketName,
 			PathPrefix:  this.pathPrefix,
+			Namespace:   this.namespace,
+			Keys:        this.keys,
 			Context:     this.context,
 			Credentials: credentials,
+			Cipher:      this.cipher,
+
+			CredentialsProvider: this.credentials,
+			ReadCache:           this.readCache,
 		}
 	}, utcNow), nil
 }
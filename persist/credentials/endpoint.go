@@ -0,0 +1,121 @@
+package credentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/smartystreets/projector/persist"
+)
+
+// Endpoint provides temporary credentials for S3 from an HTTP endpoint in the format of the ECS container
+// credentials endpoint, requesting them again shortly before they expire.
+type Endpoint struct {
+	address       string
+	authorization string
+	client        persist.HTTPClient
+	now           func() time.Time
+
+	mutex       sync.Mutex
+	credentials persist.Credentials
+	loaded      bool
+}
+
+// NewEndpoint requests the credentials from the address, sending the authorization, if any, with each request.
+func NewEndpoint(address, authorization string, client persist.HTTPClient, now func() time.Time) *Endpoint {
+	return &Endpoint{address: address, authorization: authorization, client: client, now: now}
+}
+
+// ContainerEndpoint requests the credentials from the endpoint named by AWS_CONTAINER_CREDENTIALS_FULL_URI (with
+// the authorization in AWS_CONTAINER_AUTHORIZATION_TOKEN) or AWS_CONTAINER_CREDENTIALS_RELATIVE_URI, as ECS sets.
+func ContainerEndpoint() *Endpoint {
+	address := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if relative := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); len(address) == 0 && len(relative) > 0 {
+		address = containerEndpointHost + relative
+	}
+
+	client := &http.Client{Timeout: time.Second * 5}
+	return NewEndpoint(address, os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN"), client, time.Now)
+}
+
+func (this *Endpoint) Credentials() (persist.Credentials, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	now := this.now()
+	if this.loaded && (this.credentials.Expiration.IsZero() || now.Before(this.credentials.Expiration.Add(-expirationWindow))) {
+		return this.credentials, nil
+	}
+
+	credentials, err := this.request()
+	if err == nil {
+		this.credentials, this.loaded = credentials, true
+		return credentials, nil
+	} else if this.loaded && now.Before(this.credentials.Expiration) {
+		log.Printf("[WARN] Unable to refresh credentials, continuing with those about to expire: %s\n", err)
+		return this.credentials, nil
+	}
+
+	return persist.Credentials{}, err
+}
+func (this *Endpoint) request() (persist.Credentials, error) {
+	if len(this.address) == 0 {
+		return persist.Credentials{}, fmt.Errorf("%w: no credentials endpoint specified", ErrNoCredentials)
+	}
+
+	request, err := http.NewRequest(http.MethodGet, this.address, nil)
+	if err != nil {
+		return persist.Credentials{}, err
+	}
+	if len(this.authorization) > 0 {
+		request.Header.Set("Authorization", this.authorization)
+	}
+
+	response, err := this.client.Do(request)
+	if err != nil {
+		return persist.Credentials{}, fmt.Errorf("credentials endpoint unavailable: %s", err)
+	}
+
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode != http.StatusOK {
+		return persist.Credentials{}, fmt.Errorf("credentials endpoint gave back non-200 http status code: %s", response.Status)
+	}
+
+	var body struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string
+		Token           string
+		SessionToken    string
+		Expiration      time.Time
+	}
+	if err = json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return persist.Credentials{}, fmt.Errorf("malformed credentials from endpoint: %s", err)
+	} else if len(body.AccessKeyID) == 0 || len(body.SecretAccessKey) == 0 {
+		return persist.Credentials{}, ErrIncompleteCredentials
+	}
+
+	token := strings.TrimSpace(body.Token)
+	if len(token) == 0 {
+		token = strings.TrimSpace(body.SessionToken) // as named by the credential_process format
+	}
+
+	return persist.Credentials{
+		AccessKey:    body.AccessKeyID,
+		SecretKey:    body.SecretAccessKey,
+		SessionToken: token,
+		Expiration:   body.Expiration,
+	}, nil
+}
+
+const (
+	// expirationWindow refreshes credentials before they expire, as AWS provisions new ones five minutes in advance.
+	expirationWindow = time.Minute * 5
+
+	containerEndpointHost = "http://169.254.170.2"
+)
@@ -0,0 +1,158 @@
+package credentials
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector/persist"
+)
+
+func TestEndpointFixture(t *testing.T) {
+	gunit.Run(new(EndpointFixture), t)
+}
+
+type EndpointFixture struct {
+	*gunit.Fixture
+
+	client   *FakeHTTPClient
+	now      time.Time
+	endpoint *Endpoint
+}
+
+func (this *EndpointFixture) Setup() {
+	this.client = &FakeHTTPClient{}
+	this.now = time.Date(2020, 6, 1, 11, 0, 0, 0, time.UTC)
+	this.endpoint = NewEndpoint("http://localhost/credentials", "authorization", this.client, func() time.Time { return this.now })
+}
+
+func (this *EndpointFixture) respond(accessKey string, expiration string) {
+	this.client.body = `{"AccessKeyId": "` + accessKey + `", "SecretAccessKey": "secret", "Token": "token", "Expiration": "` + expiration + `"}`
+	this.client.status = http.StatusOK
+	this.client.err = nil
+}
+
+func (this *EndpointFixture) TestTemporaryCredentialsRequested() {
+	this.respond("first", "2020-06-01T12:00:00Z")
+
+	credentials, err := this.endpoint.Credentials()
+
+	this.So(err, should.BeNil)
+	this.So(credentials, should.Resemble, persist.Credentials{
+		AccessKey:    "first",
+		SecretKey:    "secret",
+		SessionToken: "token",
+		Expiration:   time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC),
+	})
+	this.So(this.client.request.URL.String(), should.Equal, "http://localhost/credentials")
+	this.So(this.client.request.Header.Get("Authorization"), should.Equal, "authorization")
+}
+
+func (this *EndpointFixture) TestEitherNameOfSessionTokenAccepted() {
+	this.client.status = http.StatusOK
+	this.client.body = `{"AccessKeyId": "first", "SecretAccessKey": "secret", "SessionToken": "session"}`
+	named, _ := this.endpoint.Credentials()
+
+	this.endpoint = NewEndpoint("http://localhost/credentials", "", this.client, func() time.Time { return this.now })
+	this.client.body = `{"AccessKeyId": "first", "SecretAccessKey": "secret", "Token": "token", "SessionToken": "token"}`
+	both, _ := this.endpoint.Credentials()
+
+	this.So(named.SessionToken, should.Equal, "session")
+	this.So(both.SessionToken, should.Equal, "token")
+}
+
+func (this *EndpointFixture) TestCredentialsHeldUntilShortlyBeforeExpiration() {
+	this.respond("first", "2020-06-01T12:00:00Z")
+	_, _ = this.endpoint.Credentials()
+
+	this.now = time.Date(2020, 6, 1, 11, 54, 59, 0, time.UTC)
+	held, _ := this.endpoint.Credentials()
+	this.So(held.AccessKey, should.Equal, "first")
+	this.So(this.client.requests, should.Equal, 1)
+
+	this.respond("second", "2020-06-01T13:00:00Z")
+	this.now = time.Date(2020, 6, 1, 11, 55, 0, 0, time.UTC)
+	refreshed, _ := this.endpoint.Credentials()
+	this.So(refreshed.AccessKey, should.Equal, "second")
+	this.So(this.client.requests, should.Equal, 2)
+}
+
+func (this *EndpointFixture) TestCredentialsWithoutExpirationNeverRefreshed() {
+	this.client.body = `{"AccessKeyId": "first", "SecretAccessKey": "secret"}`
+	this.client.status = http.StatusOK
+	_, _ = this.endpoint.Credentials()
+
+	this.now = this.now.Add(time.Hour * 24 * 365)
+	credentials, _ := this.endpoint.Credentials()
+
+	this.So(credentials.AccessKey, should.Equal, "first")
+	this.So(this.client.requests, should.Equal, 1)
+}
+
+func (this *EndpointFixture) TestFailedRefreshProvidesCredentialsUntilExpired() {
+	this.respond("first", "2020-06-01T12:00:00Z")
+	_, _ = this.endpoint.Credentials()
+	this.client.err = errors.New("BOINK!")
+
+	this.now = time.Date(2020, 6, 1, 11, 59, 0, 0, time.UTC)
+	credentials, err := this.endpoint.Credentials()
+	this.So(err, should.BeNil)
+	this.So(credentials.AccessKey, should.Equal, "first")
+
+	this.now = time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	_, err = this.endpoint.Credentials()
+	this.So(err, should.NotBeNil)
+}
+
+func (this *EndpointFixture) TestUnsuccessfulResponseIsError() {
+	this.client.status = http.StatusForbidden
+
+	_, err := this.endpoint.Credentials()
+
+	this.So(err, should.NotBeNil)
+}
+
+func (this *EndpointFixture) TestIncompleteCredentialsAreError() {
+	this.client.body = `{"AccessKeyId": "first"}`
+	this.client.status = http.StatusOK
+
+	_, err := this.endpoint.Credentials()
+
+	this.So(err, should.Equal, ErrIncompleteCredentials)
+}
+
+func (this *EndpointFixture) TestMissingAddressIsError() {
+	_, err := NewEndpoint("", "", this.client, time.Now).Credentials()
+
+	this.So(errors.Is(err, ErrNoCredentials), should.BeTrue)
+	this.So(this.client.requests, should.Equal, 0)
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+type FakeHTTPClient struct {
+	request  *http.Request
+	requests int
+	status   int
+	body     string
+	err      error
+}
+
+func (this *FakeHTTPClient) Do(request *http.Request) (*http.Response, error) {
+	this.request = request
+	this.requests++
+	if this.err != nil {
+		return nil, this.err
+	}
+
+	return &http.Response{
+		StatusCode: this.status,
+		Status:     http.StatusText(this.status),
+		Body:       ioutil.NopCloser(strings.NewReader(this.body)),
+	}, nil
+}
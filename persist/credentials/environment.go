@@ -0,0 +1,68 @@
+package credentials
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/smartystreets/projector/persist"
+)
+
+// Environment provides the credentials named by the conventional environment variables as each request is made,
+// such as AWS_ACCESS_KEY_ID, or GOOGLE_APPLICATION_CREDENTIALS for the service account key file (which is watched).
+func Environment() persist.CredentialsProvider {
+	return newEnvironment(os.LookupEnv)
+}
+
+type environment struct {
+	lookup func(string) (string, bool)
+
+	mutex sync.Mutex
+	file  *File
+}
+
+func newEnvironment(lookup func(string) (string, bool)) *environment {
+	return &environment{lookup: lookup}
+}
+
+func (this *environment) Credentials() (persist.Credentials, error) {
+	accessKey, _ := this.lookup("AWS_ACCESS_KEY_ID")
+	secretKey, _ := this.lookup("AWS_SECRET_ACCESS_KEY")
+	sessionToken, found := this.lookup("AWS_SESSION_TOKEN")
+	if !found {
+		sessionToken, _ = this.lookup("AWS_SECURITY_TOKEN") // the name used by older tools
+	}
+	credentials := persist.Credentials{
+		AccessKey:    strings.TrimSpace(accessKey),
+		SecretKey:    strings.TrimSpace(secretKey),
+		SessionToken: strings.TrimSpace(sessionToken),
+	}
+
+	if filename, _ := this.lookup("GOOGLE_APPLICATION_CREDENTIALS"); len(strings.TrimSpace(filename)) > 0 {
+		account, err := this.serviceAccountFile(strings.TrimSpace(filename)).Credentials()
+		if err != nil {
+			return credentials, err
+		}
+		credentials.ServiceAccountKey = account.ServiceAccountKey
+	}
+
+	if len(credentials.ServiceAccountKey) > 0 {
+		return credentials, nil
+	} else if len(credentials.AccessKey) == 0 && len(credentials.SecretKey) == 0 {
+		return credentials, ErrNoCredentials
+	} else if len(credentials.AccessKey) == 0 || len(credentials.SecretKey) == 0 {
+		return credentials, ErrIncompleteCredentials
+	}
+
+	return credentials, nil
+}
+func (this *environment) serviceAccountFile(filename string) *File {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.file == nil || this.file.filename != filename {
+		this.file = NewServiceAccountFile(filename)
+	}
+
+	return this.file
+}
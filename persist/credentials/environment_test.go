@@ -0,0 +1,70 @@
+package credentials
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector/persist"
+)
+
+func TestEnvironmentFixture(t *testing.T) {
+	gunit.Run(new(EnvironmentFixture), t)
+}
+
+type EnvironmentFixture struct {
+	*gunit.Fixture
+}
+
+func (this *EnvironmentFixture) TestEnvironmentProvidesAccessKeys() {
+	environment := newEnvironment(lookup(map[string]string{"AWS_ACCESS_KEY_ID": "access", "AWS_SECRET_ACCESS_KEY": "secret"}))
+
+	credentials, err := environment.Credentials()
+
+	this.So(err, should.BeNil)
+	this.So(credentials, should.Resemble, persist.Credentials{AccessKey: "access", SecretKey: "secret"})
+}
+
+func (this *EnvironmentFixture) TestEnvironmentProvidesSessionToken() {
+	environment := newEnvironment(lookup(map[string]string{
+		"AWS_ACCESS_KEY_ID":     "access",
+		"AWS_SECRET_ACCESS_KEY": "secret",
+		"AWS_SESSION_TOKEN":     "token",
+	}))
+
+	credentials, _ := environment.Credentials()
+
+	this.So(credentials.SessionToken, should.Equal, "token")
+}
+
+func (this *EnvironmentFixture) TestEnvironmentProvidesServiceAccountKeyFromFile() {
+	file, _ := ioutil.TempFile("", "credentials")
+	_, _ = file.WriteString(`{}`)
+	_ = file.Close()
+	defer func() { _ = os.Remove(file.Name()) }()
+	environment := newEnvironment(lookup(map[string]string{"GOOGLE_APPLICATION_CREDENTIALS": file.Name()}))
+
+	credentials, err := environment.Credentials()
+
+	this.So(err, should.BeNil)
+	this.So(string(credentials.ServiceAccountKey), should.Equal, `{}`)
+}
+
+func (this *EnvironmentFixture) TestEnvironmentWithoutCredentials() {
+	_, err := newEnvironment(lookup(nil)).Credentials()
+	this.So(err, should.Equal, ErrNoCredentials)
+
+	_, err = newEnvironment(lookup(map[string]string{"AWS_ACCESS_KEY_ID": "access"})).Credentials()
+	this.So(err, should.Equal, ErrIncompleteCredentials)
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+func lookup(values map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, found := values[name]
+		return value, found
+	}
+}
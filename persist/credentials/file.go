@@ -0,0 +1,74 @@
+package credentials
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/smartystreets/projector/persist"
+)
+
+// File provides the credentials held in a file, reading it again whenever it's changed (e.g. by a secrets
+// manager rotating the credentials) but keeping those last read should it be missing or malformed.
+type File struct {
+	filename string
+	parse    func([]byte) (persist.Credentials, error)
+
+	mutex       sync.Mutex
+	modified    time.Time
+	size        int64
+	credentials persist.Credentials
+	loaded      bool
+}
+
+// NewFile watches the file, whose contents are interpreted by the parse function.
+func NewFile(filename string, parse func([]byte) (persist.Credentials, error)) *File {
+	return &File{filename: filename, parse: parse}
+}
+
+// NewServiceAccountFile watches a service account key (JSON) for Google Cloud Storage.
+func NewServiceAccountFile(filename string) *File {
+	return NewFile(filename, func(raw []byte) (persist.Credentials, error) {
+		if len(raw) == 0 {
+			return persist.Credentials{}, ErrNoCredentials
+		}
+		return persist.Credentials{ServiceAccountKey: raw}, nil
+	})
+}
+
+func (this *File) Credentials() (persist.Credentials, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	info, err := os.Stat(this.filename)
+	if err != nil {
+		return this.stale(err)
+	} else if this.loaded && info.ModTime().Equal(this.modified) && info.Size() == this.size {
+		return this.credentials, nil
+	}
+
+	raw, err := ioutil.ReadFile(this.filename)
+	if err != nil {
+		return this.stale(err)
+	}
+
+	credentials, err := this.parse(raw)
+	if err != nil {
+		return this.stale(fmt.Errorf("malformed credentials file '%s': %w", this.filename, err))
+	}
+
+	this.modified, this.size = info.ModTime(), info.Size()
+	this.credentials, this.loaded = credentials, true
+	return credentials, nil
+}
+func (this *File) stale(err error) (persist.Credentials, error) {
+	if !this.loaded {
+		return persist.Credentials{}, err
+	}
+
+	log.Printf("[WARN] Unable to refresh credentials, continuing with those read previously: %s\n", err)
+	return this.credentials, nil
+}
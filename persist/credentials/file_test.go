@@ -0,0 +1,111 @@
+package credentials
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector/persist"
+)
+
+func TestFileFixture(t *testing.T) {
+	gunit.Run(new(FileFixture), t)
+}
+
+type FileFixture struct {
+	*gunit.Fixture
+
+	directory string
+	filename  string
+	parses    int
+	file      *File
+}
+
+func (this *FileFixture) Setup() {
+	this.directory, _ = ioutil.TempDir("", "credentials")
+	this.filename = filepath.Join(this.directory, "credentials")
+	this.file = NewFile(this.filename, func(raw []byte) (persist.Credentials, error) {
+		this.parses++
+		if string(raw) == "malformed" {
+			return persist.Credentials{}, errors.New("BOINK!")
+		}
+		return persist.Credentials{AccessKey: string(raw), SecretKey: "secret"}, nil
+	})
+}
+func (this *FileFixture) Teardown() {
+	_ = os.RemoveAll(this.directory)
+}
+
+func (this *FileFixture) write(contents string, modified time.Time) {
+	_ = ioutil.WriteFile(this.filename, []byte(contents), 0600)
+	_ = os.Chtimes(this.filename, modified, modified)
+}
+
+func (this *FileFixture) TestMissingFileIsError() {
+	_, err := this.file.Credentials()
+
+	this.So(err, should.NotBeNil)
+}
+
+func (this *FileFixture) TestUnchangedFileNotReadAgain() {
+	this.write("first", time.Unix(1, 0))
+
+	first, _ := this.file.Credentials()
+	second, _ := this.file.Credentials()
+
+	this.So(first.AccessKey, should.Equal, "first")
+	this.So(second, should.Resemble, first)
+	this.So(this.parses, should.Equal, 1)
+}
+
+func (this *FileFixture) TestChangedFileReadAgain() {
+	this.write("first", time.Unix(1, 0))
+	_, _ = this.file.Credentials()
+
+	this.write("rotated", time.Unix(2, 0))
+	credentials, err := this.file.Credentials()
+
+	this.So(err, should.BeNil)
+	this.So(credentials.AccessKey, should.Equal, "rotated")
+}
+
+func (this *FileFixture) TestMalformedFileNeverReadIsError() {
+	this.write("malformed", time.Unix(1, 0))
+
+	_, err := this.file.Credentials()
+
+	this.So(err, should.NotBeNil)
+}
+
+func (this *FileFixture) TestPreviousCredentialsProvidedUntilChangedFileCorrected() {
+	this.write("first", time.Unix(1, 0))
+	_, _ = this.file.Credentials()
+
+	this.write("malformed", time.Unix(2, 0))
+	whileMalformed, err := this.file.Credentials()
+	this.So(err, should.BeNil)
+	this.So(whileMalformed.AccessKey, should.Equal, "first")
+
+	_ = os.Remove(this.filename)
+	whileMissing, err := this.file.Credentials()
+	this.So(err, should.BeNil)
+	this.So(whileMissing.AccessKey, should.Equal, "first")
+
+	this.write("corrected", time.Unix(3, 0))
+	corrected, _ := this.file.Credentials()
+	this.So(corrected.AccessKey, should.Equal, "corrected")
+}
+
+func (this *FileFixture) TestServiceAccountFileProvidesKey() {
+	this.write(`{"client_email": "projector@example.com"}`, time.Unix(1, 0))
+
+	credentials, err := NewServiceAccountFile(this.filename).Credentials()
+
+	this.So(err, should.BeNil)
+	this.So(string(credentials.ServiceAccountKey), should.Equal, `{"client_email": "projector@example.com"}`)
+}
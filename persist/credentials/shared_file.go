@@ -0,0 +1,86 @@
+package credentials
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/smartystreets/projector/persist"
+)
+
+// NewSharedCredentialsFile watches the AWS shared credentials file for the access keys of the profile, each of
+// which defaults, when blank, as with the AWS CLI (e.g. to ~/.aws/credentials and "default").
+func NewSharedCredentialsFile(filename, profile string) *File {
+	if filename = strings.TrimSpace(filename); len(filename) == 0 {
+		filename = defaultSharedCredentialsFile()
+	}
+	if profile = strings.TrimSpace(profile); len(profile) == 0 {
+		profile = defaultProfile()
+	}
+
+	return NewFile(filename, func(raw []byte) (persist.Credentials, error) {
+		return parseSharedCredentials(raw, profile)
+	})
+}
+func defaultSharedCredentialsFile() string {
+	if filename := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); len(filename) > 0 {
+		return filename
+	}
+
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".aws", "credentials")
+}
+func defaultProfile() string {
+	if profile := os.Getenv("AWS_PROFILE"); len(profile) > 0 {
+		return profile
+	}
+
+	return "default"
+}
+
+func parseSharedCredentials(raw []byte, profile string) (credentials persist.Credentials, err error) {
+	section, found := "", false
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(strings.TrimPrefix(strings.Trim(line, "[]"), "profile "))
+			found = found || section == profile
+			continue
+		} else if section != profile {
+			continue
+		}
+
+		name, value := line, ""
+		if index := strings.Index(line, "="); index >= 0 {
+			name, value = strings.TrimSpace(line[:index]), strings.TrimSpace(line[index+1:])
+		}
+
+		switch strings.ToLower(name) {
+		case "aws_access_key_id":
+			credentials.AccessKey = value
+		case "aws_secret_access_key":
+			credentials.SecretKey = value
+		case "aws_session_token", "aws_security_token":
+			credentials.SessionToken = value
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return credentials, err
+	} else if !found {
+		return credentials, fmt.Errorf("%w: '%s'", ErrProfileNotFound, profile)
+	} else if len(credentials.AccessKey) == 0 || len(credentials.SecretKey) == 0 {
+		return credentials, fmt.Errorf("%w (profile '%s')", ErrIncompleteCredentials, profile)
+	}
+
+	return credentials, nil
+}
@@ -0,0 +1,72 @@
+package credentials
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector/persist"
+)
+
+func TestSharedFileFixture(t *testing.T) {
+	gunit.Run(new(SharedFileFixture), t)
+}
+
+type SharedFileFixture struct {
+	*gunit.Fixture
+}
+
+const sharedFile = `
+# comments are ignored
+[default]
+aws_access_key_id = default-access
+aws_secret_access_key = default-secret
+
+[profile deploy]
+aws_access_key_id=deploy-access
+; so are these
+aws_secret_access_key=deploy-secret
+region = us-west-1
+
+[temporary]
+aws_access_key_id = temporary-access
+aws_secret_access_key = temporary-secret
+aws_session_token = temporary-token
+
+[incomplete]
+aws_access_key_id = incomplete-access
+`
+
+func (this *SharedFileFixture) TestProfileParsed() {
+	credentials, err := parseSharedCredentials([]byte(sharedFile), "default")
+
+	this.So(err, should.BeNil)
+	this.So(credentials, should.Resemble, persist.Credentials{AccessKey: "default-access", SecretKey: "default-secret"})
+}
+
+func (this *SharedFileFixture) TestProfilePrefixIgnored() {
+	credentials, err := parseSharedCredentials([]byte(sharedFile), "deploy")
+
+	this.So(err, should.BeNil)
+	this.So(credentials, should.Resemble, persist.Credentials{AccessKey: "deploy-access", SecretKey: "deploy-secret"})
+}
+
+func (this *SharedFileFixture) TestSessionTokenParsed() {
+	credentials, err := parseSharedCredentials([]byte(sharedFile), "temporary")
+
+	this.So(err, should.BeNil)
+	this.So(credentials.SessionToken, should.Equal, "temporary-token")
+}
+
+func (this *SharedFileFixture) TestMissingProfile() {
+	_, err := parseSharedCredentials([]byte(sharedFile), "missing")
+
+	this.So(errors.Is(err, ErrProfileNotFound), should.BeTrue)
+}
+
+func (this *SharedFileFixture) TestIncompleteProfile() {
+	_, err := parseSharedCredentials([]byte(sharedFile), "incomplete")
+
+	this.So(errors.Is(err, ErrIncompleteCredentials), should.BeTrue)
+}
@@ -0,0 +1,24 @@
+package credentials
+
+import (
+	"errors"
+
+	"github.com/smartystreets/projector/persist"
+)
+
+// Static provides the same credentials for every request; they are never rotated.
+func Static(credentials persist.Credentials) persist.CredentialsProvider {
+	return static(credentials)
+}
+
+type static persist.Credentials
+
+func (this static) Credentials() (persist.Credentials, error) {
+	return persist.Credentials(this), nil
+}
+
+var (
+	ErrNoCredentials         = errors.New("no credentials found")
+	ErrProfileNotFound       = errors.New("profile not found in the shared credentials file")
+	ErrIncompleteCredentials = errors.New("the access key and the secret key are both required")
+)
@@ -0,0 +1,175 @@
+package envelope
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log"
+)
+
+// Cipher seals each payload with a fresh data key, which is in turn sealed with the current key of the KeyProvider.
+// The ID of that key travels in the envelope rather than in object metadata, such that copies remain readable.
+type Cipher struct {
+	keys   KeyProvider
+	random io.Reader
+	strict bool
+}
+
+func NewCipher(keys KeyProvider, options ...Option) *Cipher {
+	this := &Cipher{keys: keys, random: rand.Reader}
+	for _, option := range options {
+		option(this)
+	}
+	return this
+}
+
+type Option func(*Cipher)
+
+// Strict rejects payloads which aren't envelopes with ErrNotEncrypted, rather than giving them back unchanged,
+// once every document written before encryption was enabled has been rewritten.
+func Strict() Option {
+	return func(this *Cipher) { this.strict = true }
+}
+
+func (this *Cipher) Encrypt(plaintext []byte) ([]byte, error) {
+	id, key, err := this.keys.CurrentKey()
+	if err != nil {
+		return nil, err
+	} else if len(id) == 0 || len(id) > maxKeyIDLength {
+		return nil, ErrInvalidKeyID
+	}
+
+	dataKey := make([]byte, dataKeyLength)
+	if _, err = io.ReadFull(this.random, dataKey); err != nil {
+		return nil, err
+	}
+
+	wrappedKey, err := this.seal(key, dataKey, []byte(id))
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := this.seal(dataKey, plaintext, wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := bytes.NewBuffer(make([]byte, 0, len(magic)+1+len(id)+2+len(wrappedKey)+len(sealed)))
+	buffer.Write(magic)
+	buffer.WriteByte(byte(len(id)))
+	buffer.WriteString(id)
+	_ = binary.Write(buffer, binary.BigEndian, uint16(len(wrappedKey)))
+	buffer.Write(wrappedKey)
+	buffer.Write(sealed)
+	return buffer.Bytes(), nil
+}
+func (this *Cipher) seal(key, plaintext, additional []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(this.random, nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func (this *Cipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	if !bytes.HasPrefix(ciphertext, magic) {
+		return this.unencrypted(ciphertext)
+	}
+
+	id, wrappedKey, sealed, err := parseEnvelope(ciphertext[len(magic):])
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := this.keys.Key(id)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := open(key, wrappedKey, []byte(id))
+	if err != nil {
+		return nil, err
+	}
+
+	return open(dataKey, sealed, wrappedKey)
+}
+func (this *Cipher) unencrypted(plaintext []byte) ([]byte, error) {
+	if this.strict {
+		return nil, ErrNotEncrypted
+	}
+
+	log.Printf("[WARN] Reading a document which isn't encrypted; it will be encrypted when next written.")
+	return plaintext, nil
+}
+func parseEnvelope(raw []byte) (id string, wrappedKey, sealed []byte, err error) {
+	if len(raw) < 1 {
+		return "", nil, nil, ErrMalformedEnvelope
+	}
+
+	idLength := int(raw[0])
+	raw = raw[1:]
+	if len(raw) < idLength+2 {
+		return "", nil, nil, ErrMalformedEnvelope
+	}
+
+	id = string(raw[:idLength])
+	raw = raw[idLength:]
+	keyLength := int(binary.BigEndian.Uint16(raw))
+	raw = raw[2:]
+	if len(raw) < keyLength {
+		return "", nil, nil, ErrMalformedEnvelope
+	}
+
+	return id, raw[:keyLength], raw[keyLength:], nil
+}
+func open(key, sealed, additional []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformedEnvelope
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	if plaintext, err := aead.Open(nil, nonce, ciphertext, additional); err != nil {
+		return nil, ErrMalformedEnvelope
+	} else {
+		return plaintext, nil
+	}
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, ErrInvalidKeyLength
+	}
+
+	return cipher.NewGCM(block)
+}
+
+const (
+	dataKeyLength  = 32
+	maxKeyIDLength = 255
+)
+
+var magic = []byte("PJE1")
+
+var (
+	ErrInvalidKeyID      = errors.New("the encryption key ID must be between 1 and 255 bytes")
+	ErrInvalidKeyLength  = errors.New("the encryption key must be 16, 24, or 32 bytes")
+	ErrUnknownKey        = errors.New("the encryption key could not be found")
+	ErrMalformedEnvelope = errors.New("the encrypted document is malformed or has been tampered with")
+	ErrNotEncrypted      = errors.New("the document isn't encrypted")
+)
@@ -0,0 +1,102 @@
+package envelope
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestCipherFixture(t *testing.T) {
+	gunit.Run(new(CipherFixture), t)
+}
+
+type CipherFixture struct {
+	*gunit.Fixture
+
+	keys   *KeyRing
+	cipher *Cipher
+}
+
+func (this *CipherFixture) Setup() {
+	this.keys = NewKeyRing("old", map[string][]byte{
+		"old": bytes.Repeat([]byte{1}, 32),
+		"new": bytes.Repeat([]byte{2}, 16),
+	})
+	this.cipher = NewCipher(this.keys)
+}
+
+func (this *CipherFixture) TestEncryptedPayloadRoundTrips() {
+	ciphertext, err := this.cipher.Encrypt([]byte("Hello, World!"))
+	this.So(err, should.BeNil)
+	this.So(string(ciphertext), should.NotContainSubstring, "Hello")
+
+	plaintext, err := this.cipher.Decrypt(ciphertext)
+	this.So(err, should.BeNil)
+	this.So(string(plaintext), should.Equal, "Hello, World!")
+}
+
+func (this *CipherFixture) TestPayloadSealedWithRetiredKeyRemainsReadable() {
+	ciphertext, _ := this.cipher.Encrypt([]byte("Hello, World!"))
+	this.keys.current = "new"
+
+	plaintext, err := this.cipher.Decrypt(ciphertext)
+
+	this.So(err, should.BeNil)
+	this.So(string(plaintext), should.Equal, "Hello, World!")
+}
+
+func (this *CipherFixture) TestUnknownKeyRejected() {
+	ciphertext, _ := this.cipher.Encrypt([]byte("Hello, World!"))
+	delete(this.keys.keys, "old")
+
+	plaintext, err := this.cipher.Decrypt(ciphertext)
+
+	this.So(plaintext, should.BeNil)
+	this.So(errors.Is(err, ErrUnknownKey), should.BeTrue)
+}
+
+func (this *CipherFixture) TestTamperedPayloadRejected() {
+	ciphertext, _ := this.cipher.Encrypt([]byte("Hello, World!"))
+	ciphertext[len(ciphertext)-1]++
+
+	plaintext, err := this.cipher.Decrypt(ciphertext)
+
+	this.So(plaintext, should.BeNil)
+	this.So(err, should.Equal, ErrMalformedEnvelope)
+}
+
+func (this *CipherFixture) TestTruncatedPayloadRejected() {
+	ciphertext, _ := this.cipher.Encrypt([]byte("Hello, World!"))
+
+	plaintext, err := this.cipher.Decrypt(ciphertext[:10])
+
+	this.So(plaintext, should.BeNil)
+	this.So(err, should.Equal, ErrMalformedEnvelope)
+}
+
+func (this *CipherFixture) TestUnencryptedPayloadPassesThrough() {
+	plaintext, err := this.cipher.Decrypt([]byte(`{"ID": 1234}`))
+	this.So(err, should.BeNil)
+	this.So(string(plaintext), should.Equal, `{"ID": 1234}`)
+}
+
+func (this *CipherFixture) TestUnencryptedPayloadRejectedWhenStrict() {
+	this.cipher = NewCipher(this.keys, Strict())
+
+	plaintext, err := this.cipher.Decrypt([]byte(`{"ID": 1234}`))
+
+	this.So(plaintext, should.BeNil)
+	this.So(err, should.Equal, ErrNotEncrypted)
+}
+
+func (this *CipherFixture) TestInvalidKeyLengthRejected() {
+	this.cipher = NewCipher(NewStaticKey("short", []byte("too-short")))
+
+	ciphertext, err := this.cipher.Encrypt([]byte("Hello, World!"))
+
+	this.So(ciphertext, should.BeNil)
+	this.So(err, should.Equal, ErrInvalidKeyLength)
+}
@@ -0,0 +1,90 @@
+package envelope
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// KeyProvider supplies the key-encryption keys. New payloads are always sealed with the current key,
+// while any key which is still known by its ID can be used to open existing payloads.
+type KeyProvider interface {
+	CurrentKey() (id string, key []byte, err error)
+	Key(id string) ([]byte, error)
+}
+
+type KeyRing struct {
+	current string
+	keys    map[string][]byte
+}
+
+// NewStaticKey provides a single key which never rotates.
+func NewStaticKey(id string, key []byte) *KeyRing {
+	return NewKeyRing(id, map[string][]byte{id: key})
+}
+
+// NewKeyRing provides several keys by ID, one of which is current. Retired keys
+// should remain in the ring until every document sealed with them has been rewritten.
+func NewKeyRing(current string, keys map[string][]byte) *KeyRing {
+	return &KeyRing{current: current, keys: keys}
+}
+
+func (this *KeyRing) CurrentKey() (string, []byte, error) {
+	key, err := this.Key(this.current)
+	return this.current, key, err
+}
+func (this *KeyRing) Key(id string) ([]byte, error) {
+	if key, contains := this.keys[id]; !contains {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownKey, id)
+	} else if !validKeyLength(key) {
+		return nil, ErrInvalidKeyLength
+	} else {
+		return key, nil
+	}
+}
+
+// LoadKeyFile reads a key ring from a JSON file with base64-encoded keys, for example:
+//
+//	{"current": "2020-06", "keys": {"2020-01": "base64...", "2020-06": "base64..."}}
+func LoadKeyFile(filename string) (*KeyRing, error) {
+	raw, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var contents struct {
+		Current string            `json:"current"`
+		Keys    map[string]string `json:"keys"`
+	}
+	if err = json.Unmarshal(raw, &contents); err != nil {
+		return nil, fmt.Errorf("malformed key file '%s': %s", filename, err)
+	}
+
+	keys := make(map[string][]byte, len(contents.Keys))
+	for id, encoded := range contents.Keys {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("malformed key '%s' in key file '%s': %s", id, filename, err)
+		} else if !validKeyLength(key) {
+			return nil, fmt.Errorf("%w: key '%s' in key file '%s'", ErrInvalidKeyLength, id, filename)
+		}
+		keys[id] = key
+	}
+
+	if _, contains := keys[contents.Current]; !contains {
+		return nil, fmt.Errorf("%w: current key '%s' in key file '%s'", ErrUnknownKey, contents.Current, filename)
+	}
+
+	return NewKeyRing(contents.Current, keys), nil
+}
+
+func validKeyLength(key []byte) bool {
+	switch len(key) {
+	case 16, 24, 32:
+		return true
+	default:
+		return false
+	}
+}
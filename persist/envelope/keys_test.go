@@ -0,0 +1,90 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestKeyFileFixture(t *testing.T) {
+	gunit.Run(new(KeyFileFixture), t)
+}
+
+type KeyFileFixture struct {
+	*gunit.Fixture
+
+	directory string
+}
+
+func (this *KeyFileFixture) Setup() {
+	this.directory, _ = ioutil.TempDir("", "envelope")
+}
+func (this *KeyFileFixture) Teardown() {
+	_ = os.RemoveAll(this.directory)
+}
+
+func (this *KeyFileFixture) write(contents string) string {
+	filename := filepath.Join(this.directory, "keys.json")
+	_ = ioutil.WriteFile(filename, []byte(contents), 0600)
+	return filename
+}
+
+func (this *KeyFileFixture) TestKeysLoaded() {
+	filename := this.write(`{"current": "new", "keys": {"old": "` + encode(1, 32) + `", "new": " ` + encode(2, 16) + `\n"}}`)
+
+	keys, err := LoadKeyFile(filename)
+
+	this.So(err, should.BeNil)
+	id, key, err := keys.CurrentKey()
+	this.So(err, should.BeNil)
+	this.So(id, should.Equal, "new")
+	this.So(key, should.Resemble, bytes.Repeat([]byte{2}, 16))
+	old, err := keys.Key("old")
+	this.So(err, should.BeNil)
+	this.So(old, should.Resemble, bytes.Repeat([]byte{1}, 32))
+}
+
+func (this *KeyFileFixture) TestMissingFileRejected() {
+	_, err := LoadKeyFile(filepath.Join(this.directory, "missing.json"))
+
+	this.So(err, should.NotBeNil)
+}
+
+func (this *KeyFileFixture) TestMalformedFileRejected() {
+	_, err := LoadKeyFile(this.write(`{"current": `))
+
+	this.So(err, should.NotBeNil)
+	this.So(err.Error(), should.StartWith, "malformed key file ")
+}
+
+func (this *KeyFileFixture) TestMalformedKeyRejected() {
+	_, err := LoadKeyFile(this.write(`{"current": "a", "keys": {"a": "not base64!"}}`))
+
+	this.So(err, should.NotBeNil)
+	this.So(err.Error(), should.StartWith, "malformed key 'a' in key file ")
+}
+
+func (this *KeyFileFixture) TestInvalidKeyLengthRejected() {
+	_, err := LoadKeyFile(this.write(`{"current": "a", "keys": {"a": "` + encode(1, 20) + `"}}`))
+
+	this.So(errors.Is(err, ErrInvalidKeyLength), should.BeTrue)
+}
+
+func (this *KeyFileFixture) TestUnknownCurrentKeyRejected() {
+	_, err := LoadKeyFile(this.write(`{"current": "b", "keys": {"a": "` + encode(1, 32) + `"}}`))
+
+	this.So(errors.Is(err, ErrUnknownKey), should.BeTrue)
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+func encode(value byte, length int) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{value}, length))
+}
@@ -0,0 +1,53 @@
+package gcspersist
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/smartystreets/gcs"
+)
+
+// resolve gives the settings for a single request, with the credentials
+// of the provider, if any, in place of those configured statically.
+func (this *ReadWriter) resolve() (StorageSettings, error) {
+	settings := this.settings()
+	if settings.CredentialsProvider == nil {
+		return settings, nil
+	}
+
+	provided, err := settings.CredentialsProvider.Credentials()
+	if err != nil {
+		return settings, fmt.Errorf("credentials unavailable: %w", err)
+	}
+
+	settings.Credentials, err = this.credentials.parse(provided.ServiceAccountKey)
+	return settings, err
+}
+
+// credentialsCache only parses the service account key again once it has changed.
+type credentialsCache struct {
+	mutex  sync.Mutex
+	key    []byte
+	parsed gcs.Credentials
+}
+
+func (this *credentialsCache) parse(key []byte) (gcs.Credentials, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if len(key) == 0 {
+		return gcs.Credentials{}, errors.New("credentials unavailable: no service account key provided")
+	} else if this.key != nil && bytes.Equal(key, this.key) {
+		return this.parsed, nil
+	}
+
+	parsed, err := gcs.ParseCredentialsFromJSON(key)
+	if err != nil {
+		return gcs.Credentials{}, fmt.Errorf("malformed service account key: %w", err)
+	}
+
+	this.key, this.parsed = key, parsed
+	return parsed, nil
+}
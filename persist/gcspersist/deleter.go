@@ -0,0 +1,47 @@
+package gcspersist
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist"
+)
+
+func (this *ReadWriter) Delete(document projector.Document) error {
+	settings, err := this.resolve()
+	if err != nil {
+		return err
+	}
+
+	headers := http.Header{}
+	if generation, _ := document.Version().(string); len(generation) > 0 {
+		headers.Set("x-goog-if-generation-match", generation)
+	}
+
+	request, err := newRequest(http.MethodDelete, settings, settings.keyspace().Key(document), nil, this.now().Add(time.Hour*24), headers)
+	if err != nil {
+		return fmt.Errorf("could not create signed request: %s", err)
+	}
+
+	response, err := settings.HTTPClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("http client error: '%s'", err)
+	}
+
+	defer func() { _ = response.Body.Close() }()
+
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		this.evict(settings.ReadCache, settings.keyspace().Key(document))
+		document.SetVersion(nil)
+		return nil
+	case http.StatusPreconditionFailed:
+		log.Printf("[INFO] Document on remote storage has changed '%s'\n", document.Path())
+		return persist.ErrConcurrentWrite
+	default:
+		return fmt.Errorf("non-2XX http status code: %s", response.Status)
+	}
+}
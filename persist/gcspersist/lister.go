@@ -0,0 +1,81 @@
+package gcspersist
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/smartystreets/projector/persist"
+)
+
+// List pages through the bucket using the XML API (list-type=2), following continuation tokens until exhausted.
+func (this *ReadWriter) List(prefix string, visit func(persist.DocumentInfo) error) error {
+	settings, err := this.resolve()
+	if err != nil {
+		return err
+	}
+
+	keyspace := settings.keyspace()
+	query := url.Values{"list-type": {"2"}, "prefix": {keyspace.ListPrefix(prefix)}}
+
+	for {
+		page, err := this.listPage(settings, query)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range page.Contents {
+			info, ok := keyspace.Listed(item.Key, prefix)
+			if !ok {
+				continue
+			}
+
+			info.Size, info.Version, info.LastModified = item.Size, item.Generation, item.LastModified
+			if err = visit(info); err != nil {
+				return err
+			}
+		}
+
+		if !page.IsTruncated || len(page.NextContinuationToken) == 0 {
+			return nil
+		}
+
+		query.Set("continuation-token", page.NextContinuationToken)
+	}
+}
+func (this *ReadWriter) listPage(settings StorageSettings, query url.Values) (page listBucketResult, err error) {
+	request, err := newRequest(http.MethodGet, settings, "", query, this.now().Add(time.Hour*24), nil)
+	if err != nil {
+		return page, fmt.Errorf("could not create signed request: %s", err)
+	}
+
+	response, err := settings.HTTPClient.Do(request)
+	if err != nil {
+		return page, fmt.Errorf("http client error: '%s'", err)
+	}
+
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode != http.StatusOK {
+		return page, fmt.Errorf("non-200 http status code: %s", response.Status)
+	}
+
+	if err = xml.NewDecoder(response.Body).Decode(&page); err != nil {
+		return page, fmt.Errorf("listing read error: '%s'", err)
+	}
+
+	return page, nil
+}
+
+type listBucketResult struct {
+	IsTruncated           bool
+	NextContinuationToken string
+	Contents              []struct {
+		Key          string
+		Generation   string
+		Size         int64
+		LastModified time.Time
+	}
+}
@@ -0,0 +1,140 @@
+package gcspersist
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gcs"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector/persist"
+)
+
+func TestListerFixture(t *testing.T) {
+	gunit.Run(new(ListerFixture), t)
+}
+
+type ListerFixture struct {
+	*gunit.Fixture
+
+	client     *FakeHTTPListClient
+	readWriter *ReadWriter
+	visited    []persist.DocumentInfo
+}
+
+func (this *ListerFixture) Setup() {
+	this.client = &FakeHTTPListClient{pages: []string{listPage1, listPage2}}
+	settings := StorageSettings{
+		HTTPClient:  this.client,
+		BucketName:  "bucket",
+		PathPrefix:  "projections",
+		Credentials: gcs.Credentials{BearerToken: "Bearer token"},
+	}
+	this.readWriter = NewReadWriter(func() StorageSettings { return settings }, time.Now)
+}
+func (this *ListerFixture) visit(info persist.DocumentInfo) error {
+	this.visited = append(this.visited, info)
+	return nil
+}
+
+func (this *ListerFixture) TestEveryPageListed() {
+	err := this.readWriter.List("/totals/", this.visit)
+
+	this.So(err, should.BeNil)
+	this.So(this.client.requests, should.HaveLength, 2)
+	first, second := this.client.requests[0], this.client.requests[1]
+	this.So(first.URL.Host, should.Equal, "storage.googleapis.com")
+	this.So(first.URL.Path, should.Equal, "/bucket")
+	this.So(first.URL.Query().Get("list-type"), should.Equal, "2")
+	this.So(first.URL.Query().Get("prefix"), should.Equal, "projections/totals/")
+	this.So(first.URL.Query().Get("continuation-token"), should.BeBlank)
+	this.So(first.Header.Get("Authorization"), should.Equal, "Bearer token")
+	this.So(second.URL.Query().Get("continuation-token"), should.Equal, "token-1")
+
+	this.So(this.visited, should.Resemble, []persist.DocumentInfo{
+		{Path: "/totals/1.json", Size: 11, Version: "1001", LastModified: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{Path: "/totals/2.json", Size: 22, Version: "1002", LastModified: time.Date(2020, 1, 3, 3, 4, 5, 0, time.UTC)},
+	})
+}
+
+func (this *ListerFixture) TestVisitErrorStopsListing() {
+	err := this.readWriter.List("/totals/", func(persist.DocumentInfo) error { return errors.New("STOP") })
+
+	this.So(err, should.Resemble, errors.New("STOP"))
+	this.So(this.client.requests, should.HaveLength, 1)
+}
+
+func (this *ListerFixture) TestUnsuccessfulStatusReported() {
+	this.client.statusCode = http.StatusForbidden
+
+	err := this.readWriter.List("/totals/", this.visit)
+
+	this.So(err, should.NotBeNil)
+	this.So(this.visited, should.BeEmpty)
+}
+
+func (this *ListerFixture) TestMalformedListingReported() {
+	this.client.pages = []string{"<ListBucketResult"}
+
+	err := this.readWriter.List("/totals/", this.visit)
+
+	this.So(err, should.NotBeNil)
+	this.So(err.Error(), should.StartWith, "listing read error: ")
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+type FakeHTTPListClient struct {
+	pages      []string
+	requests   []*http.Request
+	statusCode int
+}
+
+func (this *FakeHTTPListClient) Do(request *http.Request) (*http.Response, error) {
+	this.requests = append(this.requests, request)
+	if this.statusCode > 0 {
+		return &http.Response{StatusCode: this.statusCode, Status: http.StatusText(this.statusCode), Body: newHTTPBody("")}, nil
+	}
+
+	page := this.pages[0]
+	this.pages = this.pages[1:]
+	return &http.Response{StatusCode: http.StatusOK, Body: newHTTPBody(page)}, nil
+}
+
+func newHTTPBody(value string) io.ReadCloser {
+	return ioutil.NopCloser(strings.NewReader(value))
+}
+
+const listPage1 = `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult xmlns="http://doc.s3.amazonaws.com/2006-03-01">
+	<IsTruncated>true</IsTruncated>
+	<NextContinuationToken>token-1</NextContinuationToken>
+	<Contents>
+		<Key>projections/totals/1.json</Key>
+		<Generation>1001</Generation>
+		<LastModified>2020-01-02T03:04:05.000Z</LastModified>
+		<Size>11</Size>
+	</Contents>
+	<Contents>
+		<Key>elsewhere/totals/3.json</Key>
+		<Generation>1003</Generation>
+		<LastModified>2020-01-04T03:04:05.000Z</LastModified>
+		<Size>33</Size>
+	</Contents>
+</ListBucketResult>`
+
+const listPage2 = `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult xmlns="http://doc.s3.amazonaws.com/2006-03-01">
+	<IsTruncated>false</IsTruncated>
+	<Contents>
+		<Key>projections/totals/2.json</Key>
+		<Generation>1002</Generation>
+		<LastModified>2020-01-03T03:04:05.000Z</LastModified>
+		<Size>22</Size>
+	</Contents>
+</ListBucketResult>`
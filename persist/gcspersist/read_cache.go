@@ -0,0 +1,22 @@
+package gcspersist
+
+import "github.com/smartystreets/projector/persist"
+
+func (this *ReadWriter) load(cache persist.ReadCache, key string) (persist.CachedDocument, bool) {
+	if cache == nil {
+		return persist.CachedDocument{}, false
+	}
+
+	cached, found := cache.Load(key)
+	return cached, found && len(cached.Validator) > 0
+}
+func (this *ReadWriter) store(cache persist.ReadCache, key string, payload []byte, generation interface{}) {
+	if validator, _ := generation.(string); cache != nil && len(validator) > 0 {
+		cache.Store(key, persist.CachedDocument{Body: payload, Version: validator, Validator: validator})
+	}
+}
+func (this *ReadWriter) evict(cache persist.ReadCache, key string) {
+	if cache != nil {
+		cache.Evict(key)
+	}
+}
@@ -10,7 +10,6 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
-	"path"
 	"time"
 
 	"github.com/smartystreets/gcs"
@@ -19,12 +18,13 @@ import (
 )
 
 type ReadWriter struct {
-	settings func() StorageSettings
-	now      func() time.Time
+	settings    func() StorageSettings
+	now         func() time.Time
+	credentials *credentialsCache
 }
 
 func NewReadWriter(settings func() StorageSettings, now func() time.Time) *ReadWriter {
-	return &ReadWriter{settings: settings, now: now}
+	return &ReadWriter{settings: settings, now: now, credentials: &credentialsCache{}}
 }
 
 func (this *ReadWriter) Name() string { return "Google Cloud Storage" }
@@ -35,37 +35,162 @@ func (this *ReadWriter) ReadPanic(document projector.Document) {
 	}
 }
 func (this *ReadWriter) Read(document projector.Document) error {
-	settings := this.settings()
-	resource := path.Join("/", settings.PathPrefix, document.Path())
+	settings, err := this.resolve()
+	if err != nil {
+		return err
+	}
+
+	key := settings.keyspace().Key(document)
+	cached, isCached := this.load(settings.ReadCache, key)
+	request, err := this.readRequest(settings, key, cached, isCached)
+	if err != nil {
+		return fmt.Errorf("could not create signed request: %s\n", err)
+	}
+
+	response, err := settings.HTTPClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("http client error: '%s'", err)
+	}
+
+	defer func() { _ = response.Body.Close() }()
+
+	switch {
+	case response.StatusCode == http.StatusNotModified && isCached:
+		return this.decode(document, settings.Cipher, cached.Body, cached.Version)
+	case response.StatusCode == http.StatusOK:
+		payload, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return err
+		}
+		generation := response.Header.Get("x-goog-generation")
+		if err = this.decode(document, settings.Cipher, payload, generation); err == nil {
+			this.store(settings.ReadCache, key, payload, generation)
+		}
+		return err
+	case response.StatusCode == http.StatusNotFound:
+		log.Printf("[INFO] Document not found at '%s'\n", document.Path())
+		this.evict(settings.ReadCache, key)
+		return nil
+	default:
+		return fmt.Errorf("non-200 http status code: %s", response.Status)
+	}
+}
+
+// readRequest asks for the document only if its generation differs from that of the cached document, if any.
+func (this *ReadWriter) readRequest(
+	settings StorageSettings, key string, cached persist.CachedDocument, isCached bool,
+) (*http.Request, error) {
 	expiration := this.now().Add(time.Hour * 24)
+	if isCached {
+		headers := http.Header{}
+		headers.Set("x-goog-if-generation-not-match", cached.Validator)
+		return newRequest(http.MethodGet, settings, key, nil, expiration, headers)
+	}
 
-	return this.execute(resource, document, settings.HTTPClient, gcs.GET,
+	return gcs.NewRequest(gcs.GET,
 		gcs.WithCredentials(settings.Credentials),
 		gcs.WithBucket(settings.BucketName),
-		gcs.WithResource(resource),
+		gcs.WithResource("/"+key),
 		gcs.WithExpiration(expiration))
 }
 func (this *ReadWriter) Write(document projector.Document) error {
-	settings := this.settings()
-	resource := path.Join("/", settings.PathPrefix, document.Path())
+	settings, err := this.resolve()
+	if err != nil {
+		return err
+	}
+
+	return this.write(settings, document, this.serialize(document, settings.Cipher), this.contentType(settings.Cipher))
+}
+
+// ReadStored reads the document as stored, asking that its body not be transcoded (decompressed) on the way.
+func (this *ReadWriter) ReadStored(document projector.Document) (persist.StoredDocument, error) {
+	settings, err := this.resolve()
+	if err != nil {
+		return persist.StoredDocument{}, err
+	}
+
+	key, expiration, headers := settings.keyspace().Key(document), this.now().Add(time.Hour*24), http.Header{}
+	headers.Set("Accept-Encoding", "gzip")
+	request, err := newRequest(http.MethodGet, settings, key, nil, expiration, headers)
+	if err != nil {
+		return persist.StoredDocument{}, fmt.Errorf("could not create signed request: %s\n", err)
+	}
+
+	response, err := settings.HTTPClient.Do(request)
+	if err != nil {
+		return persist.StoredDocument{}, fmt.Errorf("http client error: '%s'", err)
+	}
+
+	defer func() { _ = response.Body.Close() }()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		payload, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return persist.StoredDocument{}, err
+		}
+		document.SetVersion(response.Header.Get("x-goog-generation"))
+		return persist.StoredDocument{
+			Body:            payload,
+			ContentType:     response.Header.Get("Content-Type"),
+			ContentEncoding: response.Header.Get("Content-Encoding"),
+		}, nil
+	case http.StatusNotFound:
+		return persist.StoredDocument{}, nil
+	default:
+		return persist.StoredDocument{}, fmt.Errorf("non-200 http status code: %s", response.Status)
+	}
+}
+
+// WriteStored writes the body as given, described as it was when read.
+func (this *ReadWriter) WriteStored(document projector.Document, stored persist.StoredDocument) error {
+	settings, err := this.resolve()
+	if err != nil {
+		return err
+	}
+
+	return this.write(settings, document, stored.Body, gcs.WithCompositeOption(
+		gcs.WithConditionalOption(gcs.PutWithContentType(stored.ContentType), len(stored.ContentType) > 0),
+		gcs.WithConditionalOption(gcs.PutWithContentEncoding(stored.ContentEncoding), len(stored.ContentEncoding) > 0)))
+}
+
+func (this *ReadWriter) write(
+	settings StorageSettings, document projector.Document, body []byte, contentType gcs.Option,
+) error {
+	key := settings.keyspace().Key(document)
+	resource := "/" + key
 	expiration := this.now().Add(time.Hour * 24)
 	generation, _ := document.Version().(string)
-	body := this.serialize(document)
+	if document.Version() == persist.Absent {
+		generation = "0" // GCS creates the object only if it has no live generation
+	}
 	checksum := md5.Sum(body)
 
-	return this.execute(resource, document, settings.HTTPClient, gcs.PUT,
+	err := this.execute(resource, document, settings, gcs.PUT,
 		gcs.WithCredentials(settings.Credentials),
 		gcs.WithBucket(settings.BucketName),
 		gcs.WithResource(resource),
 		gcs.WithExpiration(expiration),
 		gcs.PutWithGeneration(generation),
 		gcs.PutWithContentBytes(body),
-		gcs.PutWithContentEncoding("gzip"),
-		gcs.PutWithContentType("application/json"),
+		contentType,
 		gcs.PutWithContentMD5(checksum[:]))
+	if err == nil {
+		this.store(settings.ReadCache, key, body, document.Version())
+	}
+
+	return err
+}
+
+func (this *ReadWriter) contentType(cipher persist.Cipher) gcs.Option {
+	if cipher != nil {
+		return gcs.PutWithContentType("application/octet-stream")
+	}
+
+	return gcs.WithCompositeOption(gcs.PutWithContentEncoding("gzip"), gcs.PutWithContentType("application/json"))
 }
 
-func (this *ReadWriter) serialize(document projector.Document) []byte {
+func (this *ReadWriter) serialize(document projector.Document, cipher persist.Cipher) []byte {
 	buffer := bytes.NewBuffer([]byte{})
 	writer, _ := gzip.NewWriterLevel(buffer, gzip.BestCompression)
 
@@ -75,8 +200,16 @@ func (this *ReadWriter) serialize(document projector.Document) []byte {
 	}
 
 	_ = writer.Close() // flush the buffer too
-	return buffer.Bytes()
+	if cipher == nil {
+		return buffer.Bytes()
+	}
+
+	encrypted, err := cipher.Encrypt(buffer.Bytes())
+	if err != nil {
+		log.Panic(err)
+	}
 
+	return encrypted
 }
 func (this *ReadWriter) deserialize(document projector.Document, reader io.Reader) error {
 	err := json.NewDecoder(reader).Decode(document)
@@ -88,19 +221,19 @@ func (this *ReadWriter) deserialize(document projector.Document, reader io.Reade
 }
 
 func (this *ReadWriter) execute(
-	resource string, document projector.Document, client persist.HTTPClient, method string, options ...gcs.Option,
+	resource string, document projector.Document, settings StorageSettings, method string, options ...gcs.Option,
 ) error {
 	request, err := gcs.NewRequest(method, options...)
 	if err != nil {
 		return fmt.Errorf("could not create signed request: %s\n", err)
 	}
 
-	response, err := client.Do(request)
+	response, err := settings.HTTPClient.Do(request)
 	if err != nil {
 		return fmt.Errorf("http client error: '%s'", err)
 	}
 
-	generation, err := this.handleResponse(method, resource, document, response)
+	generation, err := this.handleResponse(method, resource, document, settings.Cipher, response)
 	if err != nil {
 		return err
 	}
@@ -109,7 +242,7 @@ func (this *ReadWriter) execute(
 	return nil
 }
 func (this *ReadWriter) handleResponse(
-	method string, resource string, document projector.Document, response *http.Response,
+	method string, resource string, document projector.Document, cipher persist.Cipher, response *http.Response,
 ) (string, error) {
 	//log.Printf(
 	//	"[INFO] HTTP %s Status [%d], Content-Length: [%d], Resource: [%s]",
@@ -118,7 +251,7 @@ func (this *ReadWriter) handleResponse(
 
 	switch response.StatusCode {
 	case http.StatusOK:
-		return response.Header.Get("x-goog-generation"), this.handleResponseBody(document, response)
+		return response.Header.Get("x-goog-generation"), this.handleResponseBody(document, cipher, response)
 	case http.StatusNotFound:
 		log.Printf("[INFO] Document not found at '%s'\n", document.Path())
 		return "", nil
@@ -129,7 +262,7 @@ func (this *ReadWriter) handleResponse(
 		return "", fmt.Errorf("non-200 http status code: %s", response.Status)
 	}
 }
-func (this *ReadWriter) handleResponseBody(document projector.Document, response *http.Response) error {
+func (this *ReadWriter) handleResponseBody(document projector.Document, cipher persist.Cipher, response *http.Response) error {
 	defer func() { _ = response.Body.Close() }()
 
 	// note "response.ContentLength == -1" means unknown length
@@ -142,11 +275,44 @@ func (this *ReadWriter) handleResponseBody(document projector.Document, response
 		return err
 	}
 
+	return this.deserializePayload(document, cipher, payload)
+}
+
+// decode reads the document from the payload as it was given back by the storage, or as it was cached.
+func (this *ReadWriter) decode(document projector.Document, cipher persist.Cipher, payload []byte, generation interface{}) error {
+	if len(payload) > 0 {
+		if err := this.deserializePayload(document, cipher, payload); err != nil {
+			return err
+		}
+	}
+
+	document.SetVersion(generation)
+	return nil
+}
+func (this *ReadWriter) deserializePayload(document projector.Document, cipher persist.Cipher, payload []byte) (err error) {
+	if cipher != nil {
+		if payload, err = cipher.Decrypt(payload); err != nil {
+			return fmt.Errorf("document decryption error: '%s'", err)
+		}
+	}
+
+	// encrypted documents are compressed before they are sealed, so no transcoding happens on the way back
+	if bytes.HasPrefix(payload, gzipHeader) {
+		if reader, err := gzip.NewReader(bytes.NewBuffer(payload)); err == nil {
+			return this.deserialize(document, reader)
+		}
+	}
+
 	if err := this.deserialize(document, bytes.NewBuffer(payload)); err == nil {
 		return nil
 	}
 
 	log.Printf("[WARN] Deserialization failed for [%s], trying to gunzip first before deserializing.", document.Path())
-	reader, _ := gzip.NewReader(bytes.NewBuffer(payload))
+	reader, err := gzip.NewReader(bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("document read error: '%s'", err)
+	}
 	return this.deserialize(document, reader)
 }
+
+var gzipHeader = []byte{0x1f, 0x8b}
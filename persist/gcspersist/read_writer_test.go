@@ -0,0 +1,168 @@
+package gcspersist
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gcs"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector"
+)
+
+func TestReadWriterFixture(t *testing.T) {
+	gunit.Run(new(ReadWriterFixture), t)
+}
+
+type ReadWriterFixture struct {
+	*gunit.Fixture
+
+	readWriter *ReadWriter
+	cipher     *FakeCipher
+}
+
+func (this *ReadWriterFixture) Setup() {
+	this.readWriter = NewReadWriter(func() StorageSettings { return StorageSettings{} }, time.Now)
+	this.cipher = &FakeCipher{}
+}
+
+func (this *ReadWriterFixture) TestSerializedAsGzippedJSON() {
+	payload := this.readWriter.serialize(&Document{Value: 42}, nil)
+
+	this.So(gunzip(payload), should.Equal, `{"Value":42}`+"\n")
+}
+
+func (this *ReadWriterFixture) TestSerializedThenEncrypted() {
+	payload := this.readWriter.serialize(&Document{Value: 42}, this.cipher)
+
+	this.So(bytes.HasPrefix(payload, []byte("sealed:")), should.BeTrue)
+	this.So(gunzip(payload[len("sealed:"):]), should.Equal, `{"Value":42}`+"\n")
+}
+
+func (this *ReadWriterFixture) TestContentDescribedAsGzippedJSON() {
+	request := this.put(this.readWriter.contentType(nil))
+
+	this.So(request.Header.Get("Content-Type"), should.Equal, "application/json")
+	this.So(request.Header.Get("Content-Encoding"), should.Equal, "gzip")
+}
+
+func (this *ReadWriterFixture) TestEncryptedContentDescribedAsOpaque() {
+	request := this.put(this.readWriter.contentType(this.cipher))
+
+	this.So(request.Header.Get("Content-Type"), should.Equal, "application/octet-stream")
+	this.So(request.Header.Get("Content-Encoding"), should.BeBlank)
+}
+func (this *ReadWriterFixture) put(option gcs.Option) *http.Request {
+	request, err := gcs.NewRequest(gcs.PUT,
+		gcs.WithCredentials(gcs.Credentials{BearerToken: "Bearer token"}),
+		gcs.WithBucket("bucket"),
+		gcs.WithResource("/document"),
+		gcs.PutWithContentBytes([]byte("body")),
+		option)
+	this.So(err, should.BeNil)
+	return request
+}
+
+func (this *ReadWriterFixture) TestGzippedResponseBodyDeserialized() {
+	document := &Document{}
+	payload := this.readWriter.serialize(&Document{Value: 42}, nil)
+
+	err := this.readWriter.handleResponseBody(document, nil, response(payload))
+
+	this.So(err, should.BeNil)
+	this.So(document.Value, should.Equal, 42)
+}
+
+func (this *ReadWriterFixture) TestTranscodedResponseBodyDeserialized() {
+	document := &Document{}
+
+	err := this.readWriter.handleResponseBody(document, nil, response([]byte(`{"Value":42}`)))
+
+	this.So(err, should.BeNil)
+	this.So(document.Value, should.Equal, 42)
+}
+
+func (this *ReadWriterFixture) TestEncryptedResponseBodyDecryptedAndDeserialized() {
+	document := &Document{}
+	payload := this.readWriter.serialize(&Document{Value: 42}, this.cipher)
+
+	err := this.readWriter.handleResponseBody(document, this.cipher, response(payload))
+
+	this.So(err, should.BeNil)
+	this.So(document.Value, should.Equal, 42)
+}
+
+func (this *ReadWriterFixture) TestUndecryptableResponseBodyRejected() {
+	this.cipher.err = errors.New("BOINK!")
+
+	err := this.readWriter.handleResponseBody(&Document{}, this.cipher, response([]byte("sealed:")))
+
+	this.So(err, should.NotBeNil)
+	this.So(err.Error(), should.Equal, "document decryption error: 'BOINK!'")
+}
+
+func (this *ReadWriterFixture) TestEmptyResponseBodyLeavesDocumentUntouched() {
+	document := &Document{Value: 1}
+
+	err := this.readWriter.handleResponseBody(document, nil, response(nil))
+
+	this.So(err, should.BeNil)
+	this.So(document.Value, should.Equal, 1)
+}
+
+func (this *ReadWriterFixture) TestMalformedResponseBodyRejected() {
+	err := this.readWriter.handleResponseBody(&Document{}, nil, response([]byte("{")))
+
+	this.So(err, should.NotBeNil)
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+type Document struct {
+	projector.VersionInfo
+
+	Value int
+}
+
+func (this *Document) Lapse(time.Time) projector.Document { return this }
+func (this *Document) Apply(interface{}) bool             { return false }
+func (this *Document) Path() string                       { return "/document" }
+func (this *Document) Reset()                             { this.Value = 0; this.VersionInfo.Reset() }
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+// FakeCipher "seals" payloads by prefixing them.
+type FakeCipher struct{ err error }
+
+func (this *FakeCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	return append([]byte("sealed:"), plaintext...), nil
+}
+func (this *FakeCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	if this.err != nil {
+		return nil, this.err
+	}
+	return bytes.TrimPrefix(ciphertext, []byte("sealed:")), nil
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+func response(body []byte) *http.Response {
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		ContentLength: int64(len(body)),
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+	}
+}
+func gunzip(payload []byte) string {
+	reader, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return "not gzipped: " + err.Error()
+	}
+	raw, _ := ioutil.ReadAll(reader)
+	return string(raw)
+}
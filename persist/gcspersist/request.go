@@ -0,0 +1,77 @@
+package gcspersist
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// newRequest builds the requests which the gcs package is unable to, such as listings and deletions,
+// and authorizes them the same way it does: with the bearer token or else a (V2) signed URL.
+func newRequest(
+	method string, settings StorageSettings, resource string, query url.Values, expiration time.Time, headers http.Header,
+) (*http.Request, error) {
+	canonicalResource := path.Join("/", settings.BucketName, resource)
+	target := &url.URL{Scheme: "https", Host: "storage.googleapis.com", Path: canonicalResource}
+	if query == nil {
+		query = url.Values{}
+	}
+
+	request, err := http.NewRequest(method, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for name := range headers {
+		request.Header.Set(name, headers.Get(name))
+	}
+
+	if credentials := settings.Credentials; len(credentials.BearerToken) > 0 {
+		request.Header.Set("Authorization", credentials.BearerToken)
+	} else {
+		epoch := strconv.FormatInt(expiration.Unix(), 10)
+		signature, err := credentials.PrivateKey.Sign(stringToSign(method, epoch, canonicalResource, request.Header))
+		if err != nil {
+			return nil, err
+		}
+
+		query.Set("GoogleAccessId", credentials.AccessID)
+		query.Set("Expires", epoch)
+		query.Set("Signature", base64.StdEncoding.EncodeToString(signature))
+	}
+
+	request.URL.RawQuery = query.Encode()
+	if settings.Context != nil {
+		request = request.WithContext(settings.Context)
+	}
+
+	return request, nil
+}
+
+// https://cloud.google.com/storage/docs/access-control/signed-urls-v2
+func stringToSign(method, epoch, canonicalResource string, headers http.Header) []byte {
+	buffer := bytes.NewBuffer(nil)
+	_, _ = fmt.Fprintf(buffer, "%s\n%s\n%s\n%s\n", method, headers.Get("Content-MD5"), headers.Get("Content-Type"), epoch)
+
+	var extensions []string
+	for name := range headers {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-goog-") {
+			extensions = append(extensions, lower+":"+strings.TrimSpace(headers.Get(name)))
+		}
+	}
+	sort.Strings(extensions)
+
+	for _, extension := range extensions {
+		buffer.WriteString(extension + "\n")
+	}
+
+	buffer.WriteString(canonicalResource)
+	return buffer.Bytes()
+}
@@ -0,0 +1,94 @@
+package gcspersist
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gcs"
+	"github.com/smartystreets/gunit"
+)
+
+func TestRequestFixture(t *testing.T) {
+	gunit.Run(new(RequestFixture), t)
+}
+
+type RequestFixture struct {
+	*gunit.Fixture
+
+	key        *rsa.PrivateKey
+	settings   StorageSettings
+	expiration time.Time
+}
+
+func (this *RequestFixture) Setup() {
+	this.key, _ = rsa.GenerateKey(rand.Reader, 1024)
+	encoded, _ := x509.MarshalPKCS8PrivateKey(this.key)
+	credentials, err := gcs.NewCredentials("projector@example.com", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: encoded}))
+	this.So(err, should.BeNil)
+
+	this.settings = StorageSettings{BucketName: "bucket", Credentials: credentials}
+	this.expiration = time.Unix(1600000000, 0)
+}
+
+func (this *RequestFixture) TestStringToSignIncludesExtensionHeadersInOrder() {
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json")
+	headers.Set("X-Goog-If-Generation-Match", " 42 ")
+	headers.Set("x-goog-generation", "41")
+	headers.Set("Accept", "ignored")
+
+	signed := stringToSign(http.MethodDelete, "1600000000", "/bucket/path/document.json", headers)
+
+	this.So(string(signed), should.Equal, "DELETE\n"+
+		"\n"+
+		"application/json\n"+
+		"1600000000\n"+
+		"x-goog-generation:41\n"+
+		"x-goog-if-generation-match:42\n"+
+		"/bucket/path/document.json")
+}
+
+func (this *RequestFixture) TestRequestSignedWithServiceAccountKey() {
+	headers := http.Header{}
+	headers.Set("x-goog-if-generation-match", "42")
+
+	request, err := newRequest(http.MethodDelete, this.settings, "path/document.json", url.Values{"a": {"b"}}, this.expiration, headers)
+
+	this.So(err, should.BeNil)
+	this.So(request.Method, should.Equal, http.MethodDelete)
+	this.So(request.URL.Host, should.Equal, "storage.googleapis.com")
+	this.So(request.URL.Path, should.Equal, "/bucket/path/document.json")
+	this.So(request.Header.Get("x-goog-if-generation-match"), should.Equal, "42")
+	this.So(request.Header.Get("Authorization"), should.BeBlank)
+
+	query := request.URL.Query()
+	this.So(query.Get("a"), should.Equal, "b")
+	this.So(query.Get("GoogleAccessId"), should.Equal, "projector@example.com")
+	this.So(query.Get("Expires"), should.Equal, "1600000000")
+
+	signature, err := base64.StdEncoding.DecodeString(query.Get("Signature"))
+	this.So(err, should.BeNil)
+	sum := sha256.Sum256(stringToSign(http.MethodDelete, "1600000000", "/bucket/path/document.json", request.Header))
+	this.So(rsa.VerifyPKCS1v15(&this.key.PublicKey, crypto.SHA256, sum[:], signature), should.BeNil)
+}
+
+func (this *RequestFixture) TestRequestAuthorizedWithBearerToken() {
+	this.settings.Credentials = gcs.Credentials{BearerToken: "Bearer token"}
+
+	request, err := newRequest(http.MethodGet, this.settings, "", url.Values{"list-type": {"2"}}, this.expiration, nil)
+
+	this.So(err, should.BeNil)
+	this.So(request.Header.Get("Authorization"), should.Equal, "Bearer token")
+	this.So(request.URL.Path, should.Equal, "/bucket")
+	this.So(request.URL.RawQuery, should.Equal, "list-type=2")
+}
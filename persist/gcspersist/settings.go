@@ -11,6 +11,21 @@ type StorageSettings struct {
 	HTTPClient  persist.HTTPClient
 	BucketName  string
 	PathPrefix  string
+	Namespace   string
+	Keys        persist.KeyMapper
 	Context     context.Context
 	Credentials gcs.Credentials
+	Cipher      persist.Cipher
+
+	// CredentialsProvider, when given, supplies the service account key as each request is
+	// made (in place of the credentials above), such that the key may be rotated.
+	CredentialsProvider persist.CredentialsProvider
+
+	// ReadCache, when given, keeps the documents read and written such that each document is only
+	// read again (with a conditional GET) when its generation has changed.
+	ReadCache persist.ReadCache
+}
+
+func (this StorageSettings) keyspace() persist.Keyspace {
+	return persist.NewKeyspace(this.Keys, this.PathPrefix, this.Namespace)
 }
@@ -0,0 +1,146 @@
+package historypersist
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist"
+)
+
+// Storage holds both the documents and their history.
+type Storage interface {
+	persist.ReadWriter
+	persist.Lister
+}
+
+// Revision identifies one immutable copy of a document, as recorded when the document was written.
+type Revision struct {
+	ID   string
+	Time time.Time
+	Size int64
+}
+
+// ReadWriter records a copy of every document it writes beneath the history prefix (e.g.
+// "/history/totals.json/20200630T000000.000000000Z"), such that earlier revisions may be read back.
+type ReadWriter struct {
+	Storage
+
+	now    func() time.Time
+	prefix string
+}
+
+func NewReadWriter(inner Storage, now func() time.Time) *ReadWriter {
+	return &ReadWriter{Storage: inner, now: now, prefix: DefaultPrefix}
+}
+
+// WithPrefix places the history beneath the prefix rather than the default.
+func (this *ReadWriter) WithPrefix(prefix string) *ReadWriter {
+	this.prefix = path.Join("/", prefix)
+	return this
+}
+
+// Write writes the document and then records a copy of it, only logging a failure to do so
+// lest the messages be applied to the document again.
+func (this *ReadWriter) Write(document projector.Document) error {
+	if err := this.Storage.Write(document); err != nil {
+		return err
+	}
+
+	revision := this.revisionPath(document, this.now().UTC().Format(revisionFormat))
+	snapshot, err := persist.Snapshot(revision, document)
+	if err == nil {
+		err = this.Storage.Write(snapshot)
+	}
+	if err != nil {
+		log.Printf("[WARN] Unable to record history of document [%s] at [%s]: %s\n", document.Path(), revision, err)
+	}
+
+	return nil
+}
+
+// Delete removes the document from the inner storage, leaving its history in place.
+func (this *ReadWriter) Delete(document projector.Document) error {
+	if deleter, ok := this.Storage.(persist.Deleter); ok {
+		return deleter.Delete(document)
+	}
+
+	return fmt.Errorf("storage [%s] is unable to delete documents", this.Storage.Name())
+}
+
+// History gives the recorded revisions of the document, oldest first.
+func (this *ReadWriter) History(document projector.Document) (revisions []Revision, err error) {
+	directory := this.revisionPath(document, "")
+	err = this.Storage.List(directory, func(info persist.DocumentInfo) error {
+		id := strings.TrimPrefix(info.Path, directory)
+		if written, err := time.Parse(revisionFormat, id); err == nil {
+			revisions = append(revisions, Revision{ID: id, Time: written, Size: info.Size})
+		}
+		return nil
+	})
+
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].ID < revisions[j].ID })
+	return revisions, err
+}
+
+// ReadAt reads the document as it was at the revision given by its ID (a string) or as of a time.Time, leaving
+// its version untouched such that the document written back replaces the current one.
+func (this *ReadWriter) ReadAt(document projector.Document, versionOrTime interface{}) error {
+	id, err := this.resolve(document, versionOrTime)
+	if err != nil {
+		return err
+	}
+
+	version := document.Version()
+	document.Reset()
+	defer document.SetVersion(version)
+
+	revision := persist.Detach(document, this.revisionPath(document, id))
+	if err = this.Storage.Read(revision); err != nil {
+		return err
+	} else if revision.Version() == nil {
+		return ErrRevisionNotFound // the storage reads a document which doesn't exist as a blank one without a version
+	}
+
+	return nil
+}
+func (this *ReadWriter) resolve(document projector.Document, versionOrTime interface{}) (string, error) {
+	switch at := versionOrTime.(type) {
+	case string:
+		if _, err := time.Parse(revisionFormat, at); err != nil {
+			return "", fmt.Errorf("malformed revision '%s': %s", at, err)
+		}
+		return at, nil
+
+	case time.Time:
+		revisions, err := this.History(document)
+		if err != nil {
+			return "", err
+		}
+		for i := len(revisions) - 1; i >= 0; i-- {
+			if !revisions[i].Time.After(at) {
+				return revisions[i].ID, nil
+			}
+		}
+		return "", ErrRevisionNotFound
+
+	default:
+		return "", fmt.Errorf("unsupported revision type: %T", versionOrTime)
+	}
+}
+
+func (this *ReadWriter) revisionPath(document projector.Document, id string) string {
+	return path.Join(this.prefix, document.Path()) + "/" + id
+}
+
+const (
+	DefaultPrefix  = "/history"
+	revisionFormat = "20060102T150405.000000000Z"
+)
+
+var ErrRevisionNotFound = errors.New("no such revision of the document was recorded")
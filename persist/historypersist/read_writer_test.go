@@ -0,0 +1,174 @@
+package historypersist
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist/persisttest"
+)
+
+func TestReadWriterFixture(t *testing.T) {
+	gunit.Run(new(ReadWriterFixture), t)
+}
+
+type ReadWriterFixture struct {
+	*gunit.Fixture
+
+	now      time.Time
+	storage  *HistoryStorage
+	history  *ReadWriter
+	document *FakeDocument
+}
+
+func (this *ReadWriterFixture) Setup() {
+	this.now = time.Date(2020, 6, 30, 12, 0, 0, 0, time.UTC)
+	this.storage = &HistoryStorage{Storage: persisttest.NewStorage("fake")}
+	this.history = NewReadWriter(this.storage, this.clock)
+	this.document = &FakeDocument{}
+}
+func (this *ReadWriterFixture) clock() time.Time { return this.now }
+
+func (this *ReadWriterFixture) write(total int) {
+	this.document.Total = total
+	_ = this.history.Write(this.document)
+	this.now = this.now.Add(time.Hour)
+}
+
+func (this *ReadWriterFixture) TestWriteRecordsImmutableCopy() {
+	this.write(42)
+
+	this.So(this.storage.Bodies, should.Resemble, map[string]string{
+		"/totals.json": `{"Total":42}`,
+		"/history/totals.json/20200630T120000.000000000Z": `{"Total":42}`,
+	})
+	this.So(this.document.Version(), should.Equal, this.storage.Version("/totals.json"))
+}
+
+func (this *ReadWriterFixture) TestHistoryBeneathConfiguredPrefix() {
+	this.history.WithPrefix("audit/trail")
+
+	this.write(42)
+
+	this.So(this.storage.Bodies, should.ContainKey, "/audit/trail/totals.json/20200630T120000.000000000Z")
+}
+
+func (this *ReadWriterFixture) TestFailedWriteRecordsNoHistory() {
+	this.storage.WriteError = errors.New("BOINK!")
+
+	err := this.history.Write(this.document)
+
+	this.So(err, should.Equal, this.storage.WriteError)
+	this.So(this.storage.Bodies, should.BeEmpty)
+}
+
+func (this *ReadWriterFixture) TestFailureToRecordHistoryNotGivenBack() {
+	this.storage.historyError = errors.New("BOINK!")
+
+	this.So(this.history.Write(this.document), should.BeNil)
+	this.So(this.storage.Bodies, should.ContainKey, "/totals.json")
+}
+
+func (this *ReadWriterFixture) TestHistoryListsRevisionsOldestFirst() {
+	this.write(1)
+	this.write(2)
+	this.storage.Put("/history/totals.json/not-a-revision", "{}")
+
+	revisions, err := this.history.History(this.document)
+
+	this.So(err, should.BeNil)
+	this.So(revisions, should.Resemble, []Revision{
+		{ID: "20200630T120000.000000000Z", Time: time.Date(2020, 6, 30, 12, 0, 0, 0, time.UTC), Size: 11},
+		{ID: "20200630T130000.000000000Z", Time: time.Date(2020, 6, 30, 13, 0, 0, 0, time.UTC), Size: 11},
+	})
+}
+
+func (this *ReadWriterFixture) TestReadAtRevision() {
+	this.write(1)
+	this.write(2)
+	document := &FakeDocument{Total: 99}
+	document.SetVersion("current")
+
+	err := this.history.ReadAt(document, "20200630T120000.000000000Z")
+
+	this.So(err, should.BeNil)
+	this.So(document.Total, should.Equal, 1)
+	this.So(document.Version(), should.Equal, "current")
+}
+
+func (this *ReadWriterFixture) TestReadAtTimeGivesLatestRevisionAtOrBefore() {
+	this.write(1)
+	this.write(2)
+	this.write(3)
+	document := &FakeDocument{}
+
+	err := this.history.ReadAt(document, time.Date(2020, 6, 30, 13, 59, 0, 0, time.UTC))
+
+	this.So(err, should.BeNil)
+	this.So(document.Total, should.Equal, 2)
+}
+
+func (this *ReadWriterFixture) TestReadAtTimeBeforeHistory() {
+	this.write(1)
+
+	err := this.history.ReadAt(&FakeDocument{}, time.Date(2020, 6, 29, 0, 0, 0, 0, time.UTC))
+
+	this.So(err, should.Equal, ErrRevisionNotFound)
+}
+
+func (this *ReadWriterFixture) TestReadAtUnknownRevision() {
+	this.write(1)
+	document := &FakeDocument{Total: 99}
+
+	err := this.history.ReadAt(document, "20200629T120000.000000000Z")
+
+	this.So(err, should.Equal, ErrRevisionNotFound)
+}
+
+func (this *ReadWriterFixture) TestDeleteLeavesHistory() {
+	this.write(1)
+
+	err := this.history.Delete(this.document)
+
+	this.So(err, should.BeNil)
+	this.So(this.storage.Bodies, should.Resemble, map[string]string{
+		"/history/totals.json/20200630T120000.000000000Z": `{"Total":1}`,
+	})
+}
+
+func (this *ReadWriterFixture) TestReadAtRejectsMalformedRevisions() {
+	this.So(this.history.ReadAt(&FakeDocument{}, "latest"), should.NotBeNil)
+	this.So(this.history.ReadAt(&FakeDocument{}, 42), should.NotBeNil)
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+type FakeDocument struct {
+	projector.VersionInfo
+
+	Total int
+}
+
+func (this *FakeDocument) Lapse(now time.Time) projector.Document { return this }
+func (this *FakeDocument) Apply(message interface{}) bool         { return false }
+func (this *FakeDocument) Path() string                           { return "/totals.json" }
+func (this *FakeDocument) Reset()                                 { this.Total = 0; this.VersionInfo.Reset() }
+
+// HistoryStorage fails to write any history, when asked to, but nothing else.
+type HistoryStorage struct {
+	*persisttest.Storage
+
+	historyError error
+}
+
+func (this *HistoryStorage) Write(document projector.Document) error {
+	if this.historyError != nil && strings.HasPrefix(document.Path(), "/history/") {
+		return this.historyError
+	}
+
+	return this.Storage.Write(document)
+}
@@ -3,6 +3,7 @@ package persist
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/smartystreets/projector"
 )
@@ -18,14 +19,113 @@ type Writer interface {
 	Write(projector.Document) error
 }
 
+// Deleter removes the document from storage. When the document has a version the removal is conditional
+// upon it, giving back ErrConcurrentWrite if the stored document has since changed. Removing a document
+// which doesn't exist isn't an error.
+type Deleter interface {
+	Delete(projector.Document) error
+}
+
 type ReadWriter interface {
 	Reader
 	Writer
 	Name() string
 }
 
+// Lister enumerates the stored documents whose paths begin with the prefix, calling visit for each one
+// until the documents are exhausted or visit gives back an error, which is then given back by List.
+type Lister interface {
+	List(prefix string, visit func(DocumentInfo) error) error
+}
+
+// StoredReadWriter reads and writes documents in the form the backend stores them, neither decoding nor encoding them,
+// such that they may be copied verbatim. A document which doesn't exist is read with an empty body. Reading sets the
+// version of the document, and writing is conditional upon it, just as with Read and Write.
+type StoredReadWriter interface {
+	ReadStored(projector.Document) (StoredDocument, error)
+	WriteStored(projector.Document, StoredDocument) error
+}
+
+// StoredDocument is the body of a document as stored, compressed (and perhaps encrypted), along with
+// the content type and encoding which describe it.
+type StoredDocument struct {
+	Body            []byte
+	ContentType     string
+	ContentEncoding string
+}
+
+// DocumentInfo describes a stored document as it is enumerated. The version
+// is the same value the backend gives to Document.SetVersion when reading.
+type DocumentInfo struct {
+	Path         string
+	Size         int64
+	Version      interface{}
+	LastModified time.Time
+
+	// Type and Date are those of the document where its key encodes them (see TemplateKeyMapper),
+	// such that a RawDocument can be stored at the same key (see NewListedDocument).
+	Type string
+	Date time.Time
+}
+
+// Cipher encrypts serialized documents before they are written and decrypts them after they are read.
+// Payloads which were not produced by Encrypt may be given back from Decrypt unchanged, which keeps documents
+// written before encryption was enabled readable. Encrypted payloads are stored as opaque octet streams.
+type Cipher interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// KeyMapper translates the path of a document into the key under which it is stored, relative to any
+// prefix of the backend, and translates such keys back into document paths when they are enumerated.
+type KeyMapper interface {
+	Key(document projector.Document) string
+	Path(key string) (path string, ok bool)
+}
+
+// Credentials authorize requests to the storage: the access key pair (and any session token) for S3,
+// or the service account key (as JSON) for Google Cloud Storage.
+type Credentials struct {
+	AccessKey         string
+	SecretKey         string
+	SessionToken      string
+	Expiration        time.Time
+	ServiceAccountKey []byte
+}
+
+// CredentialsProvider supplies the credentials for every request, such that
+// they may be rotated without rebuilding the storage.
+type CredentialsProvider interface {
+	Credentials() (Credentials, error)
+}
+
+// ReadCache holds the stored form of the documents last read or written, by key, such that a backend may ask for a
+// document only if it has changed (with a conditional GET), decoding the cached body when it hasn't. Each backend
+// should have a cache of its own, as the keys are those within the backend. Implementations must be thread-safe.
+type ReadCache interface {
+	Load(key string) (CachedDocument, bool)
+	Store(key string, document CachedDocument)
+	Evict(key string)
+}
+
+// CachedDocument is a document as stored by the backend. The body remains compressed (and perhaps encrypted),
+// the version is that given to Document.SetVersion, and the validator is the ETag by which the backend
+// recognizes the body as unchanged.
+type CachedDocument struct {
+	Body      []byte      `json:"body"`
+	Version   interface{} `json:"version"`
+	Validator string      `json:"validator"`
+}
+
 type HTTPClient interface {
 	Do(*http.Request) (*http.Response, error)
 }
 
 var ErrConcurrentWrite = errors.New("the document has been updated by another process")
+
+// Absent is the version of a document known not to be stored, such as one which was read but not found (which
+// leaves the version nil). Writing a document with this version creates it only if it still isn't stored, giving
+// back ErrConcurrentWrite should another process have written it since, whereas a nil version writes it regardless.
+var Absent interface{} = absent{}
+
+type absent struct{}
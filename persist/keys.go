@@ -0,0 +1,272 @@
+package persist
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/smartystreets/projector"
+)
+
+// Dated documents supply the date used by the {date} placeholder of a key template,
+// which is typically the beginning of the period the document covers.
+type Dated interface {
+	Date() time.Time
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+// PathKeyMapper stores each document at its path verbatim.
+type PathKeyMapper struct{}
+
+func NewPathKeyMapper() PathKeyMapper { return PathKeyMapper{} }
+
+func (this PathKeyMapper) Key(document projector.Document) string { return document.Path() }
+func (this PathKeyMapper) Path(key string) (string, bool) {
+	return "/" + strings.TrimPrefix(key, "/"), true
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+// TemplateKeyMapper stores each document at a key given by a template such as "{environment}/{type}/{date}/{path}",
+// in which only {path} is required and {date} is that of a Dated document, formatted as 2006-01-02 (or "undated").
+type TemplateKeyMapper struct {
+	template    string
+	environment string
+	pattern     *regexp.Regexp
+}
+
+func NewTemplateKeyMapper(template, environment string) (*TemplateKeyMapper, error) {
+	template = strings.Trim(template, "/")
+	if strings.Count(template, placeholderPath) != 1 {
+		return nil, errors.New("the key template must contain the {path} placeholder exactly once")
+	} else if strings.Contains(environment, "/") {
+		return nil, errors.New("the environment of a key template cannot contain a slash")
+	} else if len(environment) == 0 { // collapse its segment, lest keys have an empty one
+		template = strings.Replace(template, placeholderEnvironment+"/", "", -1)
+		template = strings.Replace(template, "/"+placeholderEnvironment, "", -1)
+	}
+
+	expression := regexp.QuoteMeta(template)
+	expression = strings.Replace(expression, regexp.QuoteMeta(placeholderEnvironment), regexp.QuoteMeta(environment), -1)
+	expression = strings.Replace(expression, regexp.QuoteMeta(placeholderType), `(?P<type>[^/]+)`, 1)
+	expression = strings.Replace(expression, regexp.QuoteMeta(placeholderType), `[^/]+`, -1)
+	expression = strings.Replace(expression, regexp.QuoteMeta(placeholderDate), `(?P<date>\d{4}-\d{2}-\d{2}|undated)`, 1)
+	expression = strings.Replace(expression, regexp.QuoteMeta(placeholderDate), `(?:\d{4}-\d{2}-\d{2}|undated)`, -1)
+	expression = strings.Replace(expression, regexp.QuoteMeta(placeholderPath), `(?P<path>.+)`, 1)
+
+	return &TemplateKeyMapper{
+		template:    template,
+		environment: environment,
+		pattern:     regexp.MustCompile("^" + expression + "$"),
+	}, nil
+}
+
+func (this *TemplateKeyMapper) Key(document projector.Document) string {
+	return strings.NewReplacer(
+		placeholderEnvironment, this.environment,
+		placeholderType, documentType(document),
+		placeholderDate, documentDate(document),
+		placeholderPath, strings.Trim(document.Path(), "/"),
+	).Replace(this.template)
+}
+func (this *TemplateKeyMapper) Path(key string) (string, bool) {
+	info, ok := this.describe(key)
+	return info.Path, ok
+}
+func (this *TemplateKeyMapper) describe(key string) (info DocumentInfo, ok bool) {
+	matches := this.pattern.FindStringSubmatch(strings.Trim(key, "/"))
+	if matches == nil {
+		return info, false
+	}
+
+	for i, name := range this.pattern.SubexpNames() {
+		switch name {
+		case "path":
+			info.Path = "/" + matches[i]
+		case "type":
+			info.Type = matches[i]
+		case "date":
+			info.Date, _ = time.Parse(dateLayout, matches[i]) // "undated" remains the zero value
+		}
+	}
+
+	return info, true
+}
+
+// describer is implemented by the mappers whose keys encode the type and date of a document as well as its path.
+type describer interface {
+	describe(key string) (DocumentInfo, bool)
+}
+
+func documentType(document projector.Document) string {
+	document = unwrap(document)
+	if raw, ok := document.(*RawDocument); ok && len(raw.kind) > 0 {
+		return raw.kind
+	}
+
+	return reflect.Indirect(reflect.ValueOf(document)).Type().Name()
+}
+func documentDate(document projector.Document) string {
+	if date, ok := dateOf(document); ok {
+		return date.Format(dateLayout)
+	}
+
+	return "undated"
+}
+func dateOf(document projector.Document) (time.Time, bool) {
+	document = unwrap(document)
+	if raw, ok := document.(*RawDocument); ok {
+		return raw.date, !raw.date.IsZero()
+	} else if dated, ok := document.(Dated); ok {
+		return dated.Date(), true
+	}
+
+	return time.Time{}, false
+}
+
+// unwrap gives the document presented by any wrappers (see Relocate and Detach).
+func unwrap(document projector.Document) projector.Document {
+	for {
+		if wrapper, ok := document.(interface{ unwrap() projector.Document }); ok {
+			document = wrapper.unwrap()
+		} else {
+			return document
+		}
+	}
+}
+
+const (
+	dateLayout             = "2006-01-02"
+	placeholderEnvironment = "{environment}"
+	placeholderType        = "{type}"
+	placeholderDate        = "{date}"
+	placeholderPath        = "{path}"
+)
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+// ShardedKeyMapper precedes the keys of the inner mapper with a few hexadecimal characters of their
+// MD5 hash, spreading documents which would otherwise share a prefix across S3 request partitions.
+type ShardedKeyMapper struct {
+	inner KeyMapper
+	width int
+}
+
+func NewShardedKeyMapper(inner KeyMapper, width int) *ShardedKeyMapper {
+	if width < 1 {
+		width = 1
+	} else if width > md5.Size*2 {
+		width = md5.Size * 2
+	}
+
+	return &ShardedKeyMapper{inner: inner, width: width}
+}
+
+func (this *ShardedKeyMapper) Key(document projector.Document) string {
+	key := strings.Trim(this.inner.Key(document), "/")
+	sum := md5.Sum([]byte(key))
+	return hex.EncodeToString(sum[:])[:this.width] + "/" + key
+}
+func (this *ShardedKeyMapper) Path(key string) (string, bool) {
+	key = strings.Trim(key, "/")
+	if len(key) <= this.width+1 || key[this.width] != '/' {
+		return "", false
+	}
+
+	shard, key := key[:this.width], key[this.width+1:]
+	sum := md5.Sum([]byte(key))
+	if hex.EncodeToString(sum[:])[:this.width] != shard {
+		return "", false
+	}
+
+	return this.inner.Path(key)
+}
+func (this *ShardedKeyMapper) describe(key string) (DocumentInfo, bool) {
+	path, ok := this.Path(key)
+	if !ok {
+		return DocumentInfo{}, false
+	} else if inner, ok := this.inner.(describer); ok {
+		return inner.describe(strings.Trim(key, "/")[this.width+1:])
+	}
+
+	return DocumentInfo{Path: path}, true
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+// Keyspace locates documents beneath a prefix of a bucket according to a KeyMapper.
+type Keyspace struct {
+	prefix string
+	mapper KeyMapper
+}
+
+// NewKeyspace joins the prefix elements; a nil mapper stores documents at their paths verbatim.
+func NewKeyspace(mapper KeyMapper, prefix ...string) Keyspace {
+	if mapper == nil {
+		mapper = NewPathKeyMapper()
+	}
+
+	return Keyspace{prefix: strings.Trim(path.Join(append([]string{"/"}, prefix...)...), "/"), mapper: mapper}
+}
+
+// Key gives the full key of the document within the bucket, without any leading slash.
+func (this Keyspace) Key(document projector.Document) string {
+	return strings.Trim(path.Join(this.prefix, this.mapper.Key(document)), "/")
+}
+
+// Path gives the path of the document stored at the full key, provided the key belongs to the keyspace.
+func (this Keyspace) Path(key string) (string, bool) {
+	info, ok := this.describe(strings.Trim(key, "/"))
+	return info.Path, ok
+}
+
+// Prefix gives the common prefix of every key in the keyspace, with a trailing slash unless it is empty.
+func (this Keyspace) Prefix() string {
+	if len(this.prefix) == 0 {
+		return ""
+	}
+
+	return this.prefix + "/"
+}
+
+// ListPrefix narrows the enumeration of documents whose paths begin with the path prefix to as few keys as the
+// mapper allows. Only verbatim paths can be narrowed beyond the prefix of the keyspace itself.
+func (this Keyspace) ListPrefix(pathPrefix string) string {
+	if _, verbatim := this.mapper.(PathKeyMapper); verbatim {
+		return this.Prefix() + strings.TrimPrefix(pathPrefix, "/")
+	}
+
+	return this.Prefix()
+}
+
+// Listed describes the document stored at the enumerated key by its path, and by its type and date where the
+// mapper encodes them, provided the key belongs to the keyspace and the path begins with the path prefix.
+func (this Keyspace) Listed(key, pathPrefix string) (DocumentInfo, bool) {
+	info, ok := this.describe(strings.Trim(key, "/"))
+	if ok && strings.HasPrefix(info.Path, "/"+strings.TrimPrefix(pathPrefix, "/")) {
+		return info, true
+	}
+
+	return DocumentInfo{}, false
+}
+func (this Keyspace) describe(key string) (DocumentInfo, bool) {
+	if len(this.prefix) > 0 {
+		if !strings.HasPrefix(key, this.prefix+"/") {
+			return DocumentInfo{}, false
+		}
+		key = key[len(this.prefix)+1:]
+	}
+
+	if mapper, ok := this.mapper.(describer); ok {
+		return mapper.describe(key)
+	}
+
+	path, ok := this.mapper.Path(key)
+	return DocumentInfo{Path: path}, ok
+}
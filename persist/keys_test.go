@@ -0,0 +1,179 @@
+package persist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector"
+)
+
+func TestKeyMapperFixture(t *testing.T) {
+	gunit.Run(new(KeyMapperFixture), t)
+}
+
+type KeyMapperFixture struct {
+	*gunit.Fixture
+
+	document *DatedDocument
+}
+
+func (this *KeyMapperFixture) Setup() {
+	this.document = &DatedDocument{path: "/totals/2020-06.json", date: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (this *KeyMapperFixture) TestPathMappedVerbatim() {
+	mapper := NewPathKeyMapper()
+
+	this.So(mapper.Key(this.document), should.Equal, "/totals/2020-06.json")
+	path, ok := mapper.Path("totals/2020-06.json")
+	this.So(path, should.Equal, "/totals/2020-06.json")
+	this.So(ok, should.BeTrue)
+}
+
+func (this *KeyMapperFixture) TestTemplateRequiresPath() {
+	mapper, err := NewTemplateKeyMapper("{environment}/{type}", "production")
+
+	this.So(mapper, should.BeNil)
+	this.So(err, should.NotBeNil)
+}
+
+func (this *KeyMapperFixture) TestTemplateRoundTrips() {
+	mapper, _ := NewTemplateKeyMapper("/{environment}/{type}/{date}/{path}/", "production")
+
+	key := mapper.Key(this.document)
+	path, ok := mapper.Path(key)
+
+	this.So(key, should.Equal, "production/DatedDocument/2020-06-01/totals/2020-06.json")
+	this.So(path, should.Equal, this.document.Path())
+	this.So(ok, should.BeTrue)
+}
+
+func (this *KeyMapperFixture) TestTemplateWithoutEnvironmentRoundTrips() {
+	mapper, _ := NewTemplateKeyMapper("{environment}/{type}/{path}/{environment}", "")
+
+	key := mapper.Key(this.document)
+	path, ok := mapper.Path(key)
+
+	this.So(key, should.Equal, "DatedDocument/totals/2020-06.json")
+	this.So(path, should.Equal, this.document.Path())
+	this.So(ok, should.BeTrue)
+}
+
+func (this *KeyMapperFixture) TestTemplateWithoutDatedDocument() {
+	mapper, _ := NewTemplateKeyMapper("{date}/{path}", "")
+
+	this.So(mapper.Key(&UndatedDocument{}), should.Equal, "undated/undated.json")
+}
+
+func (this *KeyMapperFixture) TestTemplateRejectsForeignKeys() {
+	mapper, _ := NewTemplateKeyMapper("{environment}/{type}/{path}", "production")
+
+	path, ok := mapper.Path("staging/DatedDocument/totals/2020-06.json")
+
+	this.So(path, should.BeBlank)
+	this.So(ok, should.BeFalse)
+}
+
+func (this *KeyMapperFixture) TestShardedRoundTrips() {
+	mapper := NewShardedKeyMapper(NewPathKeyMapper(), 4)
+
+	key := mapper.Key(this.document)
+	path, ok := mapper.Path(key)
+
+	this.So(key, should.EndWith, "/totals/2020-06.json")
+	this.So(key, should.HaveLength, len("abcd/totals/2020-06.json"))
+	this.So(path, should.Equal, this.document.Path())
+	this.So(ok, should.BeTrue)
+}
+
+func (this *KeyMapperFixture) TestShardedRejectsForeignKeys() {
+	mapper := NewShardedKeyMapper(NewPathKeyMapper(), 4)
+
+	_, short := mapper.Path("ab")
+	_, unsharded := mapper.Path("totals/2020-06.json")
+	_, wrongShard := mapper.Path("0000/totals/2020-06.json")
+
+	this.So(short, should.BeFalse)
+	this.So(unsharded, should.BeFalse)
+	this.So(wrongShard, should.BeFalse)
+}
+
+func (this *KeyMapperFixture) TestKeyspaceRoundTripsBeneathPrefix() {
+	keyspace := NewKeyspace(nil, "/projections/", "", "staging")
+
+	key := keyspace.Key(this.document)
+	path, ok := keyspace.Path(key)
+	_, foreign := keyspace.Path("projections/production/totals/2020-06.json")
+
+	this.So(keyspace.Prefix(), should.Equal, "projections/staging/")
+	this.So(key, should.Equal, "projections/staging/totals/2020-06.json")
+	this.So(path, should.Equal, this.document.Path())
+	this.So(ok, should.BeTrue)
+	this.So(foreign, should.BeFalse)
+}
+
+func (this *KeyMapperFixture) TestListingNarrowedOnlyForVerbatimPaths() {
+	verbatim := NewKeyspace(nil, "projections")
+	sharded := NewKeyspace(NewShardedKeyMapper(NewPathKeyMapper(), 2), "projections")
+
+	this.So(verbatim.ListPrefix("/totals/"), should.Equal, "projections/totals/")
+	this.So(sharded.ListPrefix("/totals/"), should.Equal, "projections/")
+
+	info, ok := sharded.Listed(sharded.Key(this.document), "/totals/")
+	this.So(info, should.Resemble, DocumentInfo{Path: this.document.Path()})
+	this.So(ok, should.BeTrue)
+
+	_, ok = sharded.Listed(sharded.Key(this.document), "/averages/")
+	this.So(ok, should.BeFalse)
+}
+
+func (this *KeyMapperFixture) TestListedDocumentDescribedByTemplate() {
+	mapper, _ := NewTemplateKeyMapper("{type}/{date}/{path}", "")
+	keyspace := NewKeyspace(NewShardedKeyMapper(mapper, 2), "projections")
+
+	info, ok := keyspace.Listed(keyspace.Key(this.document), "/totals/")
+	undated, _ := keyspace.Listed(keyspace.Key(&UndatedDocument{}), "/")
+
+	this.So(ok, should.BeTrue)
+	this.So(info, should.Resemble, DocumentInfo{Path: this.document.Path(), Type: "DatedDocument", Date: this.document.date})
+	this.So(undated, should.Resemble, DocumentInfo{Path: "/undated.json", Type: "UndatedDocument"})
+}
+
+func (this *KeyMapperFixture) TestWrappedDocumentsStoredAtKeyOfOriginal() {
+	mapper, _ := NewTemplateKeyMapper("{type}/{date}/{path}", "")
+	keyspace := NewKeyspace(NewShardedKeyMapper(mapper, 2), "projections")
+	key := keyspace.Key(this.document)
+	listed, _ := keyspace.Listed(key, "/")
+	snapshot, _ := Snapshot(this.document.Path(), this.document)
+
+	this.So(keyspace.Key(Relocate(this.document, this.document.Path())), should.Equal, key)
+	this.So(keyspace.Key(Detach(this.document, this.document.Path())), should.Equal, key)
+	this.So(keyspace.Key(RawDocumentOf(this.document)), should.Equal, key)
+	this.So(keyspace.Key(RawDocumentOf(this.document).Relocate(this.document.Path())), should.Equal, key)
+	this.So(keyspace.Key(NewListedDocument(listed)), should.Equal, key)
+	this.So(keyspace.Key(snapshot), should.Equal, key)
+
+	this.So(keyspace.Key(Relocate(this.document, "/elsewhere.json")), should.EndWith, "DatedDocument/2020-06-01/elsewhere.json")
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+type DatedDocument struct {
+	projector.VersionInfo
+
+	path string
+	date time.Time
+}
+
+func (this *DatedDocument) Lapse(now time.Time) projector.Document { return this }
+func (this *DatedDocument) Apply(message interface{}) bool         { return false }
+func (this *DatedDocument) Path() string                           { return this.path }
+func (this *DatedDocument) Date() time.Time                        { return this.date }
+
+type UndatedDocument struct{ projector.VersionInfo }
+
+func (this *UndatedDocument) Lapse(now time.Time) projector.Document { return this }
+func (this *UndatedDocument) Apply(message interface{}) bool         { return false }
+func (this *UndatedDocument) Path() string                           { return "/undated.json" }
@@ -0,0 +1,191 @@
+// Package persisttest provides storage for testing the packages which read and write documents.
+package persisttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist"
+)
+
+// Storage keeps documents in memory as JSON, writing and deleting them conditionally as the real backends do,
+// and versioning each by its name and a count of all writes. Errors and hooks are set before the storage is used.
+type Storage struct {
+	Bodies   map[string]string    // by path
+	Modified map[string]time.Time // as listed, by path
+
+	ReadError   error
+	WriteError  error
+	DeleteError error
+	ListError   error
+	BeforeWrite func()
+
+	Reads           int
+	Writes          int
+	Lists           int
+	WrittenVersions []interface{}
+	Deleted         []string
+	DeletedVersions []interface{}
+
+	mutex     sync.Mutex
+	name      string
+	revision  int
+	revisions map[string]int
+}
+
+func NewStorage(name string) *Storage {
+	return &Storage{
+		Bodies:    map[string]string{},
+		Modified:  map[string]time.Time{},
+		name:      name,
+		revisions: map[string]int{},
+	}
+}
+
+// Put stores the body at the path as though another process had written it.
+func (this *Storage) Put(path, body string) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.put(path, body)
+}
+func (this *Storage) put(path, body string) {
+	this.revision++
+	this.Bodies[path] = body
+	this.revisions[path] = this.revision
+}
+
+// Body gives the body stored at the path, which is blank when there's none.
+func (this *Storage) Body(path string) string {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	return this.Bodies[path]
+}
+
+// Version gives the version of the document stored at the path.
+func (this *Storage) Version(path string) string {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	return this.version(path)
+}
+func (this *Storage) version(path string) string {
+	return fmt.Sprintf("%s-%d", this.name, this.revisions[path])
+}
+
+// conflicts reports whether the version given isn't that of the document stored at the path.
+func (this *Storage) conflicts(path string, version interface{}) bool {
+	_, found := this.Bodies[path]
+	if version == persist.Absent {
+		return found
+	} else if value, _ := version.(string); len(value) > 0 {
+		return !found || value != this.version(path)
+	}
+
+	return false
+}
+
+func (this *Storage) Name() string                          { return this.name }
+func (this *Storage) ReadPanic(document projector.Document) { panic("nop") }
+func (this *Storage) Read(document projector.Document) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.Reads++
+	if this.ReadError != nil {
+		return this.ReadError
+	}
+
+	body, found := this.Bodies[document.Path()]
+	if !found {
+		return nil
+	}
+
+	document.SetVersion(this.version(document.Path()))
+	return json.Unmarshal([]byte(body), document)
+}
+func (this *Storage) Write(document projector.Document) error {
+	if this.BeforeWrite != nil {
+		this.BeforeWrite()
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.WrittenVersions = append(this.WrittenVersions, document.Version())
+	if this.WriteError != nil {
+		return this.WriteError
+	} else if this.conflicts(document.Path(), document.Version()) {
+		return persist.ErrConcurrentWrite
+	}
+
+	body, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	this.Writes++
+	this.put(document.Path(), string(body))
+	document.SetVersion(this.version(document.Path()))
+	return nil
+}
+func (this *Storage) Delete(document projector.Document) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.DeleteError != nil {
+		return this.DeleteError
+	} else if this.conflicts(document.Path(), document.Version()) {
+		return persist.ErrConcurrentWrite
+	}
+
+	this.Deleted = append(this.Deleted, document.Path())
+	this.DeletedVersions = append(this.DeletedVersions, document.Version())
+	delete(this.Bodies, document.Path())
+	document.SetVersion(nil)
+	return nil
+}
+
+// List visits the documents beneath the prefix in order of their paths.
+func (this *Storage) List(prefix string, visit func(persist.DocumentInfo) error) error {
+	listed, err := this.list(prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range listed {
+		if err := visit(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (this *Storage) list(prefix string) (listed []persist.DocumentInfo, err error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.Lists++
+	if this.ListError != nil {
+		return nil, this.ListError
+	}
+
+	for path, body := range this.Bodies {
+		if strings.HasPrefix(path, prefix) {
+			listed = append(listed, persist.DocumentInfo{
+				Path:         path,
+				Version:      this.version(path),
+				Size:         int64(len(body)),
+				LastModified: this.Modified[path],
+			})
+		}
+	}
+
+	sort.Slice(listed, func(i, j int) bool { return listed[i].Path < listed[j].Path })
+	return listed, nil
+}
@@ -0,0 +1,74 @@
+package persist
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/smartystreets/projector"
+)
+
+// RawDocument holds a stored document as undecoded JSON, which allows any document to be copied,
+// compared, or otherwise inspected without knowing its type. It never applies any messages.
+type RawDocument struct {
+	projector.VersionInfo
+
+	path string
+	body json.RawMessage
+	kind string
+	date time.Time
+}
+
+// NewRawDocument stands for a document known only by its path, which is neither typed nor dated.
+func NewRawDocument(path string) *RawDocument {
+	return &RawDocument{path: path}
+}
+
+// RawDocumentOf stands for the document, at its path, with neither a body nor a version.
+func RawDocumentOf(document projector.Document) *RawDocument {
+	date, _ := dateOf(document)
+	return &RawDocument{path: document.Path(), kind: documentType(document), date: date}
+}
+
+// NewListedDocument stands for the document enumerated by a Lister, at the version listed.
+func NewListedDocument(info DocumentInfo) *RawDocument {
+	this := &RawDocument{path: info.Path, kind: info.Type, date: info.Date}
+	this.SetVersion(info.Version)
+	return this
+}
+
+func (this *RawDocument) Lapse(time.Time) projector.Document { return this }
+func (this *RawDocument) Apply(interface{}) bool             { return false }
+func (this *RawDocument) Path() string                       { return this.path }
+func (this *RawDocument) Reset()                             { this.body = nil; this.VersionInfo.Reset() }
+
+// Body gives the JSON of the document, which is empty when the document wasn't found.
+func (this *RawDocument) Body() json.RawMessage { return this.body }
+
+// Relocate gives a copy of the document at another path, without any version.
+func (this *RawDocument) Relocate(path string) *RawDocument {
+	return &RawDocument{path: path, body: this.body, kind: this.kind, date: this.date}
+}
+
+func (this *RawDocument) MarshalJSON() ([]byte, error) {
+	if len(this.body) == 0 {
+		return []byte("null"), nil
+	}
+
+	return this.body, nil
+}
+func (this *RawDocument) UnmarshalJSON(body []byte) error {
+	this.body = append(this.body[0:0], body...)
+	return nil
+}
+
+// Snapshot captures the JSON of the document as it stands now, giving a copy at the path without any version.
+func Snapshot(path string, document projector.Document) (*RawDocument, error) {
+	body, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	this := RawDocumentOf(document)
+	this.path, this.body = path, body
+	return this, nil
+}
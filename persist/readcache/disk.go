@@ -0,0 +1,80 @@
+package readcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/smartystreets/projector/persist"
+)
+
+// Disk holds each document in a file of its own within the directory, named by the digest of its key,
+// which survives restarts. Its size is unbounded; the directory may be emptied at any time.
+type Disk struct {
+	directory string
+}
+
+func NewDisk(directory string) *Disk {
+	return &Disk{directory: directory}
+}
+
+func (this *Disk) Load(key string) (persist.CachedDocument, bool) {
+	var document persist.CachedDocument
+
+	raw, err := ioutil.ReadFile(this.filename(key))
+	if os.IsNotExist(err) {
+		return document, false
+	} else if err != nil {
+		log.Printf("[WARN] Unable to read cached document [%s]: %s\n", key, err)
+		return document, false
+	}
+
+	if err = json.Unmarshal(raw, &document); err != nil {
+		log.Printf("[WARN] Unable to decode cached document [%s]: %s\n", key, err)
+		return document, false
+	}
+
+	return document, true
+}
+
+// Store replaces the file atomically, such that a document is never loaded partially written.
+func (this *Disk) Store(key string, document persist.CachedDocument) {
+	raw, _ := json.Marshal(document)
+
+	if err := os.MkdirAll(this.directory, 0700); err != nil {
+		log.Printf("[WARN] Unable to create cache directory [%s]: %s\n", this.directory, err)
+		return
+	}
+
+	file, err := ioutil.TempFile(this.directory, ".pending-")
+	if err != nil {
+		log.Printf("[WARN] Unable to cache document [%s]: %s\n", key, err)
+		return
+	}
+
+	_, err = file.Write(raw)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), this.filename(key))
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		log.Printf("[WARN] Unable to cache document [%s]: %s\n", key, err)
+	}
+}
+func (this *Disk) Evict(key string) {
+	if err := os.Remove(this.filename(key)); err != nil && !os.IsNotExist(err) {
+		log.Printf("[WARN] Unable to evict cached document [%s]: %s\n", key, err)
+	}
+}
+
+func (this *Disk) filename(key string) string {
+	digest := sha256.Sum256([]byte(key))
+	return filepath.Join(this.directory, hex.EncodeToString(digest[:]))
+}
@@ -0,0 +1,66 @@
+package readcache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestDiskFixture(t *testing.T) {
+	gunit.Run(new(DiskFixture), t)
+}
+
+type DiskFixture struct {
+	*gunit.Fixture
+
+	directory string
+	cache     *Disk
+}
+
+func (this *DiskFixture) Setup() {
+	this.directory, _ = ioutil.TempDir("", "readcache")
+	this.cache = NewDisk(filepath.Join(this.directory, "documents"))
+}
+func (this *DiskFixture) Teardown() {
+	_ = os.RemoveAll(this.directory)
+}
+
+func (this *DiskFixture) TestStoredDocumentLoadedAcrossInstances() {
+	this.cache.Store("/a/b.json", document("body", "1"))
+
+	loaded, found := NewDisk(filepath.Join(this.directory, "documents")).Load("/a/b.json")
+
+	this.So(found, should.BeTrue)
+	this.So(loaded, should.Resemble, document("body", "1"))
+}
+
+func (this *DiskFixture) TestMissingDocumentNotLoaded() {
+	_, found := this.cache.Load("/a/b.json")
+
+	this.So(found, should.BeFalse)
+}
+
+func (this *DiskFixture) TestCorruptDocumentNotLoaded() {
+	this.cache.Store("/a/b.json", document("body", "1"))
+	_ = ioutil.WriteFile(this.cache.filename("/a/b.json"), []byte("{"), 0600)
+
+	_, found := this.cache.Load("/a/b.json")
+
+	this.So(found, should.BeFalse)
+}
+
+func (this *DiskFixture) TestEvictedDocumentRemoved() {
+	this.cache.Store("/a/b.json", document("body", "1"))
+
+	this.cache.Evict("/a/b.json")
+	this.cache.Evict("/a/b.json")
+
+	_, found := this.cache.Load("/a/b.json")
+	this.So(found, should.BeFalse)
+	files, _ := ioutil.ReadDir(filepath.Join(this.directory, "documents"))
+	this.So(files, should.BeEmpty)
+}
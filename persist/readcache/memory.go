@@ -0,0 +1,71 @@
+package readcache
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/smartystreets/projector/persist"
+)
+
+// Memory holds documents in memory up to a total size (of keys and bodies), beyond which
+// those least recently used are evicted.
+type Memory struct {
+	mutex    sync.Mutex
+	capacity int
+	size     int
+	order    *list.List // of *memoryEntry, most recently used first
+	entries  map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key      string
+	document persist.CachedDocument
+}
+
+func NewMemory(capacity int) *Memory {
+	return &Memory{capacity: capacity, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (this *Memory) Load(key string) (persist.CachedDocument, bool) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	element, found := this.entries[key]
+	if !found {
+		return persist.CachedDocument{}, false
+	}
+
+	this.order.MoveToFront(element)
+	return element.Value.(*memoryEntry).document, true
+}
+func (this *Memory) Store(key string, document persist.CachedDocument) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.evict(key)
+	if size := entrySize(key, document); size <= this.capacity {
+		this.entries[key] = this.order.PushFront(&memoryEntry{key: key, document: document})
+		this.size += size
+	}
+
+	for this.size > this.capacity {
+		this.evict(this.order.Back().Value.(*memoryEntry).key)
+	}
+}
+func (this *Memory) Evict(key string) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.evict(key)
+}
+func (this *Memory) evict(key string) {
+	if element, found := this.entries[key]; found {
+		entry := this.order.Remove(element).(*memoryEntry)
+		delete(this.entries, key)
+		this.size -= entrySize(entry.key, entry.document)
+	}
+}
+
+func entrySize(key string, document persist.CachedDocument) int {
+	return len(key) + len(document.Body) + len(document.Validator)
+}
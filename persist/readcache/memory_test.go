@@ -0,0 +1,82 @@
+package readcache
+
+import (
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector/persist"
+)
+
+func TestMemoryFixture(t *testing.T) {
+	gunit.Run(new(MemoryFixture), t)
+}
+
+type MemoryFixture struct {
+	*gunit.Fixture
+
+	cache *Memory
+}
+
+func (this *MemoryFixture) Setup() {
+	this.cache = NewMemory(30)
+}
+
+func (this *MemoryFixture) TestStoredDocumentLoaded() {
+	this.cache.Store("a", document("body", "1"))
+
+	loaded, found := this.cache.Load("a")
+
+	this.So(found, should.BeTrue)
+	this.So(loaded, should.Resemble, document("body", "1"))
+}
+
+func (this *MemoryFixture) TestReplacedDocumentCountedOnce() {
+	this.cache.Store("a", document("0123456789", "1"))
+	this.cache.Store("a", document("0123456789", "2"))
+
+	this.So(this.cache.size, should.Equal, 12)
+}
+
+func (this *MemoryFixture) TestLeastRecentlyUsedEvictedBeyondCapacity() {
+	this.cache.Store("a", document("0123456789", "1"))
+	this.cache.Store("b", document("0123456789", "1"))
+	_, _ = this.cache.Load("a")
+	this.cache.Store("c", document("0123456789", "1"))
+
+	_, foundA := this.cache.Load("a")
+	_, foundB := this.cache.Load("b")
+	_, foundC := this.cache.Load("c")
+
+	this.So(foundA, should.BeTrue)
+	this.So(foundB, should.BeFalse)
+	this.So(foundC, should.BeTrue)
+	this.So(this.cache.size, should.Equal, 24)
+}
+
+func (this *MemoryFixture) TestDocumentLargerThanCapacityNotStored() {
+	this.cache.Store("a", document("0123456789", "1"))
+	this.cache.Store("b", document("0123456789012345678901234567890123456789", "1"))
+
+	_, foundA := this.cache.Load("a")
+	_, foundB := this.cache.Load("b")
+
+	this.So(foundA, should.BeTrue)
+	this.So(foundB, should.BeFalse)
+}
+
+func (this *MemoryFixture) TestEvictedDocumentNotLoaded() {
+	this.cache.Store("a", document("body", "1"))
+
+	this.cache.Evict("a")
+
+	_, found := this.cache.Load("a")
+	this.So(found, should.BeFalse)
+	this.So(this.cache.size, should.Equal, 0)
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+func document(body, validator string) persist.CachedDocument {
+	return persist.CachedDocument{Body: []byte(body), Version: validator, Validator: validator}
+}
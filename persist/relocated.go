@@ -0,0 +1,38 @@
+package persist
+
+import (
+	"encoding/json"
+
+	"github.com/smartystreets/projector"
+)
+
+// Relocate presents the document at another path, such as beneath a prefix, sharing its version.
+func Relocate(document projector.Document, path string) projector.Document {
+	return &relocated{Document: document, path: path}
+}
+
+// Detach presents the document at another path with a version of its own, such that
+// reading or writing the copy at that path leaves the version of the document untouched.
+func Detach(document projector.Document, path string) projector.Document {
+	return &detached{relocated: relocated{Document: document, path: path}}
+}
+
+type relocated struct {
+	projector.Document
+
+	path string
+}
+
+func (this *relocated) Path() string                    { return this.path }
+func (this *relocated) unwrap() projector.Document      { return this.Document }
+func (this *relocated) MarshalJSON() ([]byte, error)    { return json.Marshal(this.Document) }
+func (this *relocated) UnmarshalJSON(body []byte) error { return json.Unmarshal(body, this.Document) }
+
+type detached struct {
+	relocated
+	version projector.VersionInfo
+}
+
+func (this *detached) Reset()                       { this.Document.Reset(); this.version.Reset() }
+func (this *detached) SetVersion(value interface{}) { this.version.SetVersion(value) }
+func (this *detached) Version() interface{}         { return this.version.Version() }
@@ -0,0 +1,71 @@
+package s3persist
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist"
+)
+
+type Deleter struct {
+	location    location
+	signer      signer
+	credentials persist.CredentialsProvider
+	client      persist.HTTPClient
+	keyspace    persist.Keyspace
+	cache       persist.ReadCache
+}
+
+func NewDeleter(storageAddress *url.URL, accessKey, secretKey string, client persist.HTTPClient, options ...Option) *Deleter {
+	config := newConfiguration(accessKey, secretKey, options)
+	location := newLocation(storageAddress)
+	return &Deleter{
+		location:    location,
+		signer:      newSigner(storageAddress, accessKey, secretKey),
+		credentials: config.credentials,
+		client:      client,
+		keyspace:    config.keyspace(location),
+		cache:       config.cache,
+	}
+}
+
+func (this *Deleter) Delete(document projector.Document) error {
+	_, signer, err := authorize(this.credentials, this.signer)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodDelete, this.location.url(this.keyspace.Key(document), nil).String(), nil)
+	if err != nil {
+		return fmt.Errorf("Could not create signed request: '%s'", err.Error())
+	}
+
+	if etag, _ := document.Version().(string); len(etag) > 0 {
+		request.Header.Set("If-Match", etag)
+	}
+	signer.Sign(request)
+
+	response, err := this.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("HTTP Client Error: '%s'", err.Error())
+	}
+
+	defer func() { _ = response.Body.Close() }()
+
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		if this.cache != nil {
+			this.cache.Evict(this.keyspace.Key(document))
+		}
+		document.SetVersion(nil)
+		return nil
+	case http.StatusPreconditionFailed:
+		log.Printf("[INFO] Document on remote storage has changed '%s'\n", document.Path())
+		return persist.ErrConcurrentWrite
+	default:
+		return fmt.Errorf("Non-2XX HTTP Status Code: %d %s", response.StatusCode, response.Status)
+	}
+}
@@ -0,0 +1,94 @@
+package s3persist
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist"
+)
+
+func TestDeleterFixture(t *testing.T) {
+	gunit.Run(new(DeleterFixture), t)
+}
+
+type DeleterFixture struct {
+	*gunit.Fixture
+
+	client   *FakeHTTPGetClient
+	deleter  *Deleter
+	document *DocumentForDeleting
+}
+
+func (this *DeleterFixture) Setup() {
+	this.client = &FakeHTTPGetClient{}
+	address := urlParsed("https://bucket.s3-us-west-1.amazonaws.com/")
+	this.deleter = NewDeleter(address, "access", "secret", this.client, PathPrefix("projections"))
+	this.document = &DocumentForDeleting{}
+	this.document.SetVersion(`"etag"`)
+}
+
+func (this *DeleterFixture) TestDeletionConditionalUponVersion() {
+	this.client.response = &http.Response{StatusCode: http.StatusNoContent, Body: newHTTPBody("")}
+
+	err := this.deleter.Delete(this.document)
+
+	this.So(err, should.BeNil)
+	this.So(this.client.request.Method, should.Equal, http.MethodDelete)
+	this.So(this.client.request.URL.Path, should.Equal, "/bucket/projections/this/is/the/path.json")
+	this.So(this.client.request.Header.Get("If-Match"), should.Equal, `"etag"`)
+	this.So(this.client.request.Header.Get("Authorization"), should.StartWith, "AWS4-HMAC-SHA256")
+	this.So(this.document.Version(), should.BeNil)
+}
+
+func (this *DeleterFixture) TestUnversionedDeletionUnconditional() {
+	this.document.SetVersion(nil)
+	this.client.response = &http.Response{StatusCode: http.StatusNoContent, Body: newHTTPBody("")}
+
+	_ = this.deleter.Delete(this.document)
+
+	this.So(this.client.request.Header, should.NotContainKey, "If-Match")
+}
+
+func (this *DeleterFixture) TestMissingDocumentNotAnError() {
+	this.client.response = &http.Response{StatusCode: http.StatusNotFound, Body: newHTTPBody("")}
+
+	this.So(this.deleter.Delete(this.document), should.BeNil)
+}
+
+func (this *DeleterFixture) TestChangedDocumentIsConcurrentWrite() {
+	this.client.response = &http.Response{StatusCode: http.StatusPreconditionFailed, Body: newHTTPBody("")}
+
+	err := this.deleter.Delete(this.document)
+
+	this.So(err, should.Equal, persist.ErrConcurrentWrite)
+	this.So(this.document.Version(), should.Equal, `"etag"`)
+}
+
+func (this *DeleterFixture) TestClientErrorReported() {
+	this.client.err = errors.New("BOINK!")
+
+	this.So(this.deleter.Delete(this.document), should.NotBeNil)
+}
+
+func (this *DeleterFixture) TestRotatedCredentialsSignRequest() {
+	provider := &FakeCredentialsProvider{credentials: persist.Credentials{AccessKey: "rotated", SecretKey: "secret"}}
+	this.deleter = NewDeleter(urlParsed("https://bucket.s3-us-west-1.amazonaws.com/"), "access", "secret", this.client,
+		Credentials(provider))
+	this.client.response = &http.Response{StatusCode: http.StatusNoContent, Body: newHTTPBody("")}
+
+	this.So(this.deleter.Delete(this.document), should.BeNil)
+	this.So(this.client.request.Header.Get("Authorization"), should.ContainSubstring, "Credential=rotated/")
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+type DocumentForDeleting struct{ projector.VersionInfo }
+
+func (this *DocumentForDeleting) Lapse(now time.Time) projector.Document { return this }
+func (this *DocumentForDeleting) Apply(message interface{}) bool         { return false }
+func (this *DocumentForDeleting) Path() string                           { return "/this/is/the/path.json" }
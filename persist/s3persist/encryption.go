@@ -0,0 +1,84 @@
+package s3persist
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"net/http"
+
+	"github.com/smartystreets/s3"
+)
+
+// Encryption describes how S3 encrypts documents at rest.
+type Encryption struct {
+	algorithm   s3.ServerSideEncryptionValue
+	kmsKeyID    string
+	customerKey []byte
+}
+
+var (
+	// EncryptionAES256 has S3 encrypt documents with keys it manages (SSE-S3). This is the default.
+	EncryptionAES256 = Encryption{algorithm: s3.ServerSideEncryptionAES256}
+
+	// EncryptionNone omits the server-side encryption headers entirely, which is required
+	// by some S3-compatible stores (e.g. MinIO) which reject them.
+	EncryptionNone = Encryption{}
+)
+
+// EncryptionKMS has S3 encrypt documents using the specified AWS KMS key (SSE-KMS).
+// When the key ID is blank, S3 uses the AWS managed key for the account.
+func EncryptionKMS(keyID string) Encryption {
+	return Encryption{algorithm: s3.ServerSideEncryptionAWSKMS, kmsKeyID: keyID}
+}
+
+// EncryptionCustomerKey has S3 encrypt documents using the provided 256-bit key (SSE-C).
+// The same key must accompany every subsequent read of those documents.
+func EncryptionCustomerKey(key []byte) Encryption {
+	return Encryption{customerKey: key}
+}
+
+// Validate ensures that a customer-provided key is suitable for SSE-C.
+func (this Encryption) Validate() error {
+	if this.customerKey != nil && len(this.customerKey) != 32 {
+		return errors.New("the customer-provided encryption key for S3 must be 256 bits")
+	}
+	return nil
+}
+
+// option specifies the portion of the encryption which the s3 package is able to sign on its own.
+func (this Encryption) option() s3.Option {
+	return s3.ConditionalOption(s3.ServerSideEncryption(this.algorithm), len(this.algorithm) > 0)
+}
+
+// headers supplies any remaining x-amz-* headers which must be signed separately. SSE-C headers
+// are required on reads as well as writes; the other modes only apply to writes.
+func (this Encryption) headers(method string) http.Header {
+	headers := http.Header{}
+
+	if len(this.kmsKeyID) > 0 && method == s3.PUT {
+		headers.Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", this.kmsKeyID)
+	}
+
+	if len(this.customerKey) > 0 {
+		checksum := md5.Sum(this.customerKey)
+		headers.Set("X-Amz-Server-Side-Encryption-Customer-Algorithm", "AES256")
+		headers.Set("X-Amz-Server-Side-Encryption-Customer-Key", base64.StdEncoding.EncodeToString(this.customerKey))
+		headers.Set("X-Amz-Server-Side-Encryption-Customer-Key-Md5", base64.StdEncoding.EncodeToString(checksum[:]))
+	}
+
+	return headers
+}
+
+// apply adds any headers the s3 package could not and, if there were any, signs the request again.
+func (this Encryption) apply(request *http.Request, signer signer) {
+	headers := this.headers(request.Method)
+	if len(headers) == 0 {
+		return
+	}
+
+	for name := range headers {
+		request.Header.Set(name, headers.Get(name))
+	}
+
+	signer.Sign(request)
+}
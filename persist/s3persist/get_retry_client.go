@@ -35,6 +35,8 @@ func (this *GetRetryClient) Do(request *http.Request) (*http.Response, error) {
 			return response, nil
 		} else if err == nil && response.StatusCode == http.StatusNotFound {
 			return response, nil
+		} else if err == nil && response.StatusCode == http.StatusNotModified {
+			return response, nil // a conditional GET of a cached document
 		} else if err != nil {
 			log.Println("[WARN] Unexpected response from target storage:", err)
 		} else if response.Body != nil {
@@ -58,6 +58,19 @@ func (this *GetRetryClientFixture) TestClientFindsNODocumentOnFirstTry() {
 
 // ///////////////////////////////////////////////////////
 
+func (this *GetRetryClientFixture) TestClientFindsDocumentUnchangedOnFirstTry() {
+	this.fakeClient.statusCode = http.StatusNotModified
+	request, _ := http.NewRequest("GET", "/document", nil)
+	this.response, this.err = this.retryClient.Do(request)
+	if this.So(this.response, should.NotBeNil) {
+		this.So(this.response.StatusCode, should.Equal, http.StatusNotModified)
+	}
+	this.So(this.err, should.BeNil)
+	this.So(this.fakeClient.calls, should.Equal, 1)
+}
+
+// ///////////////////////////////////////////////////////
+
 func (this *GetRetryClientFixture) TestClientFailsAtFirst_ThenSucceeds() {
 	this.fakeClient.statusCode = http.StatusOK
 	request, _ := http.NewRequest("GET", "/fail-first", nil)
@@ -0,0 +1,101 @@
+package s3persist
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/smartystreets/projector/persist"
+)
+
+type Lister struct {
+	location    location
+	signer      signer
+	credentials persist.CredentialsProvider
+	client      persist.HTTPClient
+	keyspace    persist.Keyspace
+}
+
+func NewLister(storageAddress *url.URL, accessKey, secretKey string, client persist.HTTPClient, options ...Option) *Lister {
+	config := newConfiguration(accessKey, secretKey, options)
+	location := newLocation(storageAddress)
+	return &Lister{
+		location:    location,
+		signer:      newSigner(storageAddress, accessKey, secretKey),
+		credentials: config.credentials,
+		client:      client,
+		keyspace:    config.keyspace(location),
+	}
+}
+
+// List pages through the bucket using ListObjectsV2, following continuation tokens until exhausted.
+func (this *Lister) List(prefix string, visit func(persist.DocumentInfo) error) error {
+	query := url.Values{"list-type": {"2"}, "prefix": {this.keyspace.ListPrefix(prefix)}}
+
+	for {
+		page, err := this.listPage(query)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range page.Contents {
+			info, ok := this.keyspace.Listed(item.Key, prefix)
+			if !ok {
+				continue
+			}
+
+			info.Size, info.Version, info.LastModified = item.Size, item.ETag, item.LastModified
+			if err = visit(info); err != nil {
+				return err
+			}
+		}
+
+		if !page.IsTruncated || len(page.NextContinuationToken) == 0 {
+			return nil
+		}
+
+		query.Set("continuation-token", page.NextContinuationToken)
+	}
+}
+func (this *Lister) listPage(query url.Values) (page listBucketResult, err error) {
+	_, signer, err := authorize(this.credentials, this.signer)
+	if err != nil {
+		return page, err
+	}
+
+	request, err := http.NewRequest(http.MethodGet, this.location.url("", query).String(), nil)
+	if err != nil {
+		return page, fmt.Errorf("Could not create signed request: '%s'", err.Error())
+	}
+	signer.Sign(request)
+
+	response, err := this.client.Do(request)
+	if err != nil {
+		return page, fmt.Errorf("HTTP Client Error: '%s'", err.Error())
+	}
+
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode != http.StatusOK {
+		return page, fmt.Errorf("Non-200 HTTP Status Code: %d %s", response.StatusCode, response.Status)
+	}
+
+	if err = xml.NewDecoder(response.Body).Decode(&page); err != nil {
+		return page, fmt.Errorf("Listing read error: '%s'", err.Error())
+	}
+
+	return page, nil
+}
+
+type listBucketResult struct {
+	IsTruncated           bool
+	NextContinuationToken string
+	Contents              []struct {
+		Key          string
+		ETag         string
+		Size         int64
+		LastModified time.Time
+	}
+}
@@ -0,0 +1,51 @@
+package s3persist
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/smartystreets/s3"
+)
+
+// location separates the endpoint, region, and bucket of the storage address from any key it carries,
+// which then serves as the outermost prefix of the keyspace.
+type location struct {
+	endpoint string
+	region   string
+	bucket   string
+	key      string
+}
+
+func newLocation(address *url.URL) location {
+	endpoint, region, bucket, key := s3.EndpointRegionBucketKey(address)
+	if len(region) == 0 {
+		region = "us-east-1"
+	}
+
+	return location{endpoint: endpoint, region: region, bucket: bucket, key: key}
+}
+
+func (this location) option() s3.Option {
+	return s3.CompositeOption(
+		s3.ConditionalOption(s3.Endpoint(this.endpoint), len(this.endpoint) > 0),
+		s3.Region(this.region),
+		s3.Bucket(this.bucket),
+	)
+}
+
+// url mirrors the path-style addressing of the s3 package for requests it is unable to build.
+func (this location) url(key string, query url.Values) *url.URL {
+	builder := new(strings.Builder)
+	if len(this.endpoint) > 0 {
+		builder.WriteString(this.endpoint)
+	} else if this.region == "us-east-1" {
+		builder.WriteString("https://s3.amazonaws.com")
+	} else {
+		builder.WriteString("https://s3-" + this.region + ".amazonaws.com")
+	}
+
+	parsed, _ := url.Parse(builder.String())
+	parsed.Path = "/" + this.bucket + "/" + s3.TrimKey(key)
+	parsed.RawQuery = query.Encode()
+	return parsed
+}
@@ -0,0 +1,69 @@
+package s3persist
+
+import (
+	"github.com/smartystreets/projector/persist"
+	"github.com/smartystreets/projector/persist/credentials"
+)
+
+type Option func(*configuration)
+
+// EncryptWith seals documents on the client before they are written and opens them after they are read.
+func EncryptWith(cipher persist.Cipher) Option {
+	return func(this *configuration) { this.cipher = cipher }
+}
+
+// ServerSideEncryption specifies how S3 should encrypt documents at rest; the default is EncryptionAES256.
+func ServerSideEncryption(value Encryption) Option {
+	return func(this *configuration) { this.encryption = value }
+}
+
+// PathPrefix places every document beneath the prefix, in addition to any key given with the storage address.
+func PathPrefix(value string) Option {
+	return func(this *configuration) { this.pathPrefix = value }
+}
+
+// Namespace places every document beneath the namespace (e.g. the environment) within the path prefix,
+// which allows multiple environments or tenants to share one bucket.
+func Namespace(value string) Option {
+	return func(this *configuration) { this.namespace = value }
+}
+
+// Credentials supplies the access keys as each request is made, such that they may be rotated without
+// rebuilding the storage. The provider takes the place of any keys given when the storage is created.
+func Credentials(provider persist.CredentialsProvider) Option {
+	return func(this *configuration) { this.credentials = provider }
+}
+
+// CacheReads keeps the documents read and written in the cache, asking S3 for each document
+// only if its ETag has changed and decoding the cached document when it hasn't.
+func CacheReads(cache persist.ReadCache) Option {
+	return func(this *configuration) { this.cache = cache }
+}
+
+// MapKeys stores each document at the key given by the mapper rather than at its path.
+func MapKeys(mapper persist.KeyMapper) Option {
+	return func(this *configuration) { this.mapper = mapper }
+}
+
+type configuration struct {
+	cipher      persist.Cipher
+	encryption  Encryption
+	pathPrefix  string
+	namespace   string
+	mapper      persist.KeyMapper
+	credentials persist.CredentialsProvider
+	cache       persist.ReadCache
+}
+
+func newConfiguration(accessKey, secretKey string, options []Option) configuration {
+	this := configuration{encryption: EncryptionAES256}
+	this.credentials = credentials.Static(persist.Credentials{AccessKey: accessKey, SecretKey: secretKey})
+	for _, option := range options {
+		option(&this)
+	}
+	return this
+}
+
+func (this configuration) keyspace(location location) persist.Keyspace {
+	return persist.NewKeyspace(this.mapper, location.key, this.pathPrefix, this.namespace)
+}
@@ -40,6 +40,8 @@ func (this *PutRetryClient) Do(request *http.Request) (*http.Response, error) {
 			log.Println("[WARN] Unexpected response from target storage:", err)
 		} else if response != nil && response.StatusCode == http.StatusPreconditionFailed {
 			return response, nil // this isn't an error
+		} else if response != nil && isConditional(request) && rejected(response) {
+			return response, nil // nor is this (see rejected); retrying would never succeed
 		} else if err != nil && response != nil && current > logAfterAttempts {
 			log.Println("[WARN] Unexpected response from target storage:", err, response.StatusCode, response.Status)
 		} else if err == nil && response.Body != nil && current > logAfterAttempts {
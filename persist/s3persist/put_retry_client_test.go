@@ -88,6 +88,20 @@ func (this *PutRetryClientFixture) TestClientRetriesBadStatus_ThenSucceeds() {
 
 // //////////////////////////////////////////////////////////////////
 
+func (this *PutRetryClientFixture) TestRejectedConditionalWriteNotRetried() {
+	request := buildRequestFromPath("/bad-status")
+	request.Header.Set("If-Match", "etag")
+
+	this.response, this.err = this.retryClient.Do(request)
+
+	this.So(this.response.StatusCode, should.Equal, http.StatusNotFound)
+	this.So(this.err, should.BeNil)
+	this.So(this.fakeClient.calls, should.Equal, 1)
+	this.So(this.naps, should.BeEmpty)
+}
+
+// //////////////////////////////////////////////////////////////////
+
 func buildRequestFromPath(path string) *http.Request {
 	request, _ := http.NewRequest("PUT", path, nil)
 	request.Body = newNopCloser([]byte(bodyPayload))
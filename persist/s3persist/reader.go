@@ -1,10 +1,12 @@
 package s3persist
 
 import (
+	"bytes"
 	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
@@ -16,23 +18,44 @@ import (
 
 type Reader struct {
 	storage     s3.Option
-	credentials s3.Option
+	credentials persist.CredentialsProvider
 	client      persist.HTTPClient
+	cipher      persist.Cipher
+	encryption  Encryption
+	signer      signer
+	keyspace    persist.Keyspace
+	cache       persist.ReadCache
 }
 
-func NewReader(storageAddress *url.URL, accessKey, secretKey string, client persist.HTTPClient) *Reader {
+func NewReader(storageAddress *url.URL, accessKey, secretKey string, client persist.HTTPClient, options ...Option) *Reader {
+	config := newConfiguration(accessKey, secretKey, options)
+	location := newLocation(storageAddress)
 	return &Reader{
-		storage:     s3.StorageAddress(storageAddress),
-		credentials: s3.Credentials(accessKey, secretKey),
+		storage:     location.option(),
+		credentials: config.credentials,
 		client:      client,
+		cipher:      config.cipher,
+		encryption:  config.encryption,
+		signer:      newSigner(storageAddress, accessKey, secretKey),
+		keyspace:    config.keyspace(location),
+		cache:       config.cache,
 	}
 }
 
 func (this *Reader) Read(document projector.Document) error {
-	request, err := s3.NewRequest(s3.GET, this.credentials, this.storage, s3.Key(document.Path()))
+	credentials, signer, err := authorize(this.credentials, this.signer)
+	if err != nil {
+		return err
+	}
+
+	key := this.keyspace.Key(document)
+	cached, isCached := this.load(key)
+	request, err := s3.NewRequest(s3.GET, credentials, this.storage, s3.Key(key),
+		s3.ConditionalOption(s3.IfNoneMatch(cached.Validator), isCached))
 	if err != nil {
 		return fmt.Errorf("Could not create signed request: '%s'", err.Error())
 	}
+	this.encryption.apply(request, signer)
 
 	response, err := this.client.Do(request)
 	if err != nil {
@@ -43,25 +66,122 @@ func (this *Reader) Read(document projector.Document) error {
 
 	if response.StatusCode == http.StatusNotFound {
 		log.Printf("[INFO] Document not found at '%s'\n", document.Path())
+		this.evict(key)
 		return nil
+	} else if response.StatusCode == http.StatusNotModified && isCached {
+		return this.decode(document, cached.Body, cached.Version)
+	}
+
+	payload, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("Document read error: '%s'", err.Error())
+	}
+
+	etag := response.Header.Get("ETag")
+	if err = this.decode(document, payload, etag); err == nil && response.StatusCode == http.StatusOK {
+		this.store(key, payload, etag)
+	}
+	return err
+}
+
+// ReadStored reads the document as stored, asking that its body not be decompressed on the way.
+func (this *Reader) ReadStored(document projector.Document) (persist.StoredDocument, error) {
+	credentials, signer, err := authorize(this.credentials, this.signer)
+	if err != nil {
+		return persist.StoredDocument{}, err
+	}
+
+	request, err := s3.NewRequest(s3.GET, credentials, this.storage, s3.Key(this.keyspace.Key(document)))
+	if err != nil {
+		return persist.StoredDocument{}, fmt.Errorf("Could not create signed request: '%s'", err.Error())
+	}
+	this.encryption.apply(request, signer)
+	request.Header.Set("Accept-Encoding", "gzip") // otherwise the body is decompressed transparently
+
+	response, err := this.client.Do(request)
+	if err != nil {
+		return persist.StoredDocument{}, fmt.Errorf("HTTP Client Error: '%s'", err.Error())
+	}
+
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode == http.StatusNotFound {
+		return persist.StoredDocument{}, nil
+	} else if response.StatusCode != http.StatusOK {
+		return persist.StoredDocument{}, fmt.Errorf("Non-200 HTTP Status Code: %d %s", response.StatusCode, response.Status)
 	}
 
-	reader := response.Body.(io.Reader)
-	if response.Header.Get("Content-Encoding") == "gzip" {
-		reader, _ = gzip.NewReader(reader)
+	payload, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return persist.StoredDocument{}, fmt.Errorf("Document read error: '%s'", err.Error())
 	}
 
-	decoder := json.NewDecoder(reader)
+	document.SetVersion(response.Header.Get("ETag"))
+	return persist.StoredDocument{
+		Body:            payload,
+		ContentType:     response.Header.Get("Content-Type"),
+		ContentEncoding: response.Header.Get("Content-Encoding"),
+	}, nil
+}
+
+func (this *Reader) decode(document projector.Document, payload []byte, version interface{}) (err error) {
+	if payload, err = this.decrypt(payload); err != nil {
+		return fmt.Errorf("Document decryption error: '%s'", err.Error())
+	}
+
+	decoder := json.NewDecoder(this.decompress(payload))
 	if err := decoder.Decode(document); err != nil {
 		return fmt.Errorf("Document read error: '%s'", err.Error())
 	}
 
-	document.SetVersion(response.Header.Get("ETag"))
+	document.SetVersion(version)
 	return nil
 }
 
+func (this *Reader) load(key string) (persist.CachedDocument, bool) {
+	if this.cache == nil {
+		return persist.CachedDocument{}, false
+	}
+
+	cached, found := this.cache.Load(key)
+	return cached, found && len(cached.Validator) > 0
+}
+func (this *Reader) store(key string, payload []byte, etag string) {
+	if this.cache != nil && len(etag) > 0 {
+		this.cache.Store(key, persist.CachedDocument{Body: payload, Version: etag, Validator: etag})
+	}
+}
+func (this *Reader) evict(key string) {
+	if this.cache != nil {
+		this.cache.Evict(key)
+	}
+}
+func (this *Reader) decrypt(payload []byte) ([]byte, error) {
+	if this.cipher == nil {
+		return payload, nil
+	}
+
+	return this.cipher.Decrypt(payload)
+}
+
+// decompress inspects the payload rather than the Content-Encoding header because encrypted
+// documents are compressed before they are sealed and so cannot declare their encoding.
+func (this *Reader) decompress(payload []byte) io.Reader {
+	if !bytes.HasPrefix(payload, gzipHeader) {
+		return bytes.NewReader(payload)
+	}
+
+	if reader, err := gzip.NewReader(bytes.NewReader(payload)); err == nil {
+		return reader
+	}
+
+	return bytes.NewReader(payload)
+}
+
 func (this *Reader) ReadPanic(document projector.Document) {
 	if err := this.Read(document); err != nil {
 		log.Panic(err)
 	}
 }
+
+var gzipHeader = []byte{0x1f, 0x8b}
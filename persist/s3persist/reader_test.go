@@ -15,6 +15,8 @@ import (
 	"github.com/smartystreets/assertions/should"
 	"github.com/smartystreets/gunit"
 	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist"
+	"github.com/smartystreets/projector/persist/readcache"
 )
 
 func TestReaderFixture(t *testing.T) {
@@ -89,6 +91,138 @@ func (this *ReaderFixture) TestValidCompressedResponse_PopulatesDocument() {
 	this.read()
 	this.So(this.document.ID, should.Equal, 1234)
 }
+func (this *ReaderFixture) TestEncryptedResponse_DecryptedAndPopulatesDocument() {
+	address := urlParsed("https://bucket.s3-us-west-1.amazonaws.com/")
+	this.reader = NewReader(address, "access", "secret", this.client, EncryptWith(&FakeCipher{}))
+
+	targetBuffer := bytes.NewBufferString("encrypted:")
+	writer := gzip.NewWriter(targetBuffer)
+	_, _ = io.WriteString(writer, `{"ID": 1234}`)
+	_ = writer.Close()
+
+	this.client.response = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(targetBuffer)}
+	this.read()
+	this.So(this.document.ID, should.Equal, 1234)
+}
+func (this *ReaderFixture) TestCustomerKeyEncryption_KeyAccompaniesRead() {
+	address := urlParsed("https://bucket.s3-us-west-1.amazonaws.com/")
+	key := []byte("0123456789abcdef0123456789abcdef")
+	this.reader = NewReader(address, "access", "secret", this.client, ServerSideEncryption(EncryptionCustomerKey(key)))
+	this.client.response = &http.Response{StatusCode: 200, Body: newHTTPBody(`{"ID": 1234}`)}
+
+	this.read()
+
+	headers := this.client.request.Header
+	this.So(headers.Get("X-Amz-Server-Side-Encryption-Customer-Algorithm"), should.Equal, "AES256")
+	this.So(headers.Get("X-Amz-Server-Side-Encryption-Customer-Key"), should.NotBeBlank)
+	this.So(headers.Get("Authorization"), should.ContainSubstring, "x-amz-server-side-encryption-customer-key")
+}
+func (this *ReaderFixture) TestPathPrefixAndNamespacePrecedeDocumentPath() {
+	address := urlParsed("https://bucket.s3-us-west-1.amazonaws.com/")
+	this.reader = NewReader(address, "access", "secret", this.client, PathPrefix("/projections/"), Namespace("staging"))
+	this.client.response = &http.Response{StatusCode: 200, Body: newHTTPBody(`{"ID": 1234}`)}
+
+	this.read()
+
+	this.So(this.client.request.URL.Path, should.Equal, "/bucket/projections/staging/this/is/the/path.json")
+}
+func (this *ReaderFixture) TestKMSEncryption_NothingAccompaniesRead() {
+	address := urlParsed("https://bucket.s3-us-west-1.amazonaws.com/")
+	this.reader = NewReader(address, "access", "secret", this.client, ServerSideEncryption(EncryptionKMS("key-id")))
+	this.client.response = &http.Response{StatusCode: 200, Body: newHTTPBody(`{"ID": 1234}`)}
+
+	this.read()
+
+	this.So(this.client.request.Header.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"), should.BeBlank)
+}
+func (this *ReaderFixture) TestStoredDocumentReadUndecoded() {
+	this.client.response = &http.Response{StatusCode: 200, Body: newHTTPBody("sealed"), Header: http.Header{}}
+	this.client.response.Header.Set("Content-Type", "application/json")
+	this.client.response.Header.Set("Content-Encoding", "gzip")
+
+	stored, err := this.reader.ReadStored(this.document)
+
+	this.So(err, should.BeNil)
+	this.So(stored, should.Resemble, persist.StoredDocument{
+		Body: []byte("sealed"), ContentType: "application/json", ContentEncoding: "gzip",
+	})
+	this.So(this.client.request.Header.Get("Accept-Encoding"), should.Equal, "gzip")
+}
+func (this *ReaderFixture) TestStoredDocumentNotFoundReadEmpty() {
+	this.client.response = &http.Response{StatusCode: 404, Body: newHTTPBody("Not found")}
+
+	stored, err := this.reader.ReadStored(this.document)
+
+	this.So(err, should.BeNil)
+	this.So(stored.Body, should.BeEmpty)
+}
+func (this *ReaderFixture) TestCredentialsResolvedForEachRequest() {
+	provider := &FakeCredentialsProvider{credentials: persist.Credentials{AccessKey: "first", SecretKey: "secret"}}
+	address := urlParsed("https://bucket.s3-us-west-1.amazonaws.com/")
+	this.reader = NewReader(address, "", "", this.client, Credentials(provider))
+
+	this.client.response = &http.Response{StatusCode: 200, Body: newHTTPBody(`{"ID": 1}`)}
+	this.read()
+	this.So(this.client.request.Header.Get("Authorization"), should.ContainSubstring, "Credential=first/")
+
+	provider.credentials.AccessKey = "rotated"
+	this.client.response = &http.Response{StatusCode: 200, Body: newHTTPBody(`{"ID": 2}`)}
+	this.read()
+	this.So(this.client.request.Header.Get("Authorization"), should.ContainSubstring, "Credential=rotated/")
+}
+func (this *ReaderFixture) TestTemporaryCredentialsCarrySessionToken() {
+	provider := &FakeCredentialsProvider{credentials: persist.Credentials{
+		AccessKey: "temporary", SecretKey: "secret", SessionToken: "token", Expiration: time.Now().Add(time.Hour),
+	}}
+	address := urlParsed("https://bucket.s3-us-west-1.amazonaws.com/")
+	this.reader = NewReader(address, "", "", this.client, Credentials(provider),
+		ServerSideEncryption(EncryptionCustomerKey(make([]byte, 32))))
+	this.client.response = &http.Response{StatusCode: 200, Body: newHTTPBody(`{"ID": 1}`)}
+
+	this.read()
+
+	this.So(this.client.request.Header.Get("X-Amz-Security-Token"), should.Equal, "token")
+	this.So(this.client.request.Header.Get("Authorization"), should.ContainSubstring, "x-amz-security-token")
+}
+func (this *ReaderFixture) TestUnavailableCredentialsPreventRequest() {
+	provider := &FakeCredentialsProvider{err: errors.New("BOINK!")}
+	address := urlParsed("https://bucket.s3-us-west-1.amazonaws.com/")
+	this.reader = NewReader(address, "", "", this.client, Credentials(provider))
+
+	err := this.reader.Read(this.document)
+
+	this.So(errors.Is(err, provider.err), should.BeTrue)
+	this.So(this.client.request, should.BeNil)
+}
+func (this *ReaderFixture) TestUnchangedDocumentServedFromCache() {
+	cache := readcache.NewMemory(1024)
+	address := urlParsed("https://bucket.s3-us-west-1.amazonaws.com/")
+	this.reader = NewReader(address, "access", "secret", this.client, CacheReads(cache))
+	this.client.response = &http.Response{StatusCode: 200, Body: newHTTPBody(`{"ID": 1234}`), Header: http.Header{}}
+	this.client.response.Header.Set("ETag", `"abc"`)
+	this.read()
+	this.So(this.client.request.Header.Get("If-None-Match"), should.BeBlank)
+
+	this.document = &Document{}
+	this.client.response = &http.Response{StatusCode: 304, Body: newHTTPBody("")}
+	this.read()
+
+	this.So(this.client.request.Header.Get("If-None-Match"), should.Equal, `"abc"`)
+	this.So(this.document.ID, should.Equal, 1234)
+}
+func (this *ReaderFixture) TestMissingDocumentEvictedFromCache() {
+	cache := readcache.NewMemory(1024)
+	cache.Store("this/is/the/path.json", persist.CachedDocument{Body: []byte(`{"ID": 1234}`), Version: `"abc"`, Validator: `"abc"`})
+	address := urlParsed("https://bucket.s3-us-west-1.amazonaws.com/")
+	this.reader = NewReader(address, "access", "secret", this.client, CacheReads(cache))
+	this.client.response = &http.Response{StatusCode: 404, Body: newHTTPBody("Not found")}
+
+	this.read()
+
+	_, found := cache.Load("this/is/the/path.json")
+	this.So(found, should.BeFalse)
+	this.So(this.document.ID, should.Equal, 0)
+}
 func (this *ReaderFixture) read() {
 	this.reader.ReadPanic(this.document)
 }
@@ -112,6 +246,17 @@ func (this *FakeHTTPGetClient) Do(request *http.Request) (*http.Response, error)
 
 // /////////////////////////////////////////////////////////////////////////////////////////
 
+type FakeCredentialsProvider struct {
+	credentials persist.Credentials
+	err         error
+}
+
+func (this *FakeCredentialsProvider) Credentials() (persist.Credentials, error) {
+	return this.credentials, this.err
+}
+
+// /////////////////////////////////////////////////////////////////////////////////////////
+
 type Document struct{ ID int }
 
 func (this *Document) Lapse(now time.Time) (next projector.Document) { return this }
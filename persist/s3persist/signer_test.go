@@ -0,0 +1,96 @@
+package s3persist
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector/persist"
+	"github.com/smartystreets/s3"
+)
+
+func TestSignerFixture(t *testing.T) {
+	gunit.Run(new(SignerFixture), t)
+}
+
+type SignerFixture struct {
+	*gunit.Fixture
+
+	signer signer
+}
+
+func (this *SignerFixture) Setup() {
+	this.signer = newSigner(urlParsed("https://bucket.s3-us-west-1.amazonaws.com/"), "access", "secret")
+}
+
+func (this *SignerFixture) TestRegionDefaultsLikeS3Package() {
+	this.So(this.signer.region, should.Equal, "us-west-1")
+	this.So(newSigner(urlParsed("http://localhost:9000/bucket"), "a", "s").region, should.Equal, "us-east-1")
+}
+
+func (this *SignerFixture) TestSignatureMatchesS3PackageForGET() {
+	request := this.buildRequest(s3.GET)
+	expected := request.Header.Get("Authorization")
+
+	this.signer.Sign(request)
+
+	this.So(request.Header.Get("Authorization"), should.Equal, expected)
+}
+
+func (this *SignerFixture) TestSignatureMatchesS3PackageForPUT() {
+	request := this.buildRequest(s3.PUT,
+		s3.ContentString("Hello, World!"),
+		s3.ContentType("application/json"),
+		s3.ContentMD5("checksum"),
+		s3.ServerSideEncryption(s3.ServerSideEncryptionAES256))
+	expected := request.Header.Get("Authorization")
+
+	this.signer.Sign(request)
+
+	this.So(request.Header.Get("Authorization"), should.Equal, expected)
+}
+
+func (this *SignerFixture) TestAdditionalAmazonHeadersAreSigned() {
+	request := this.buildRequest(s3.GET)
+	request.Header.Set("X-Amz-Server-Side-Encryption-Customer-Algorithm", "AES256")
+
+	this.signer.Sign(request)
+
+	this.So(request.Header.Get("Authorization"), should.ContainSubstring,
+		"x-amz-date;x-amz-server-side-encryption-customer-algorithm, Signature=")
+}
+
+func (this *SignerFixture) TestSignatureWithSessionTokenMatchesS3Package() {
+	request := this.buildRequest(s3.GET, s3.STSCredentials("temporary", "secret", "token", time.Time{}))
+	expected := request.Header.Get("Authorization")
+	request.Header.Del("X-Amz-Security-Token")
+
+	this.signer.with(persist.Credentials{AccessKey: "temporary", SecretKey: "secret", SessionToken: "token"}).Sign(request)
+
+	this.So(request.Header.Get("X-Amz-Security-Token"), should.Equal, "token")
+	this.So(request.Header.Get("Authorization"), should.Equal, expected)
+	this.So(expected, should.ContainSubstring, "x-amz-security-token")
+}
+
+func (this *SignerFixture) TestUnpreparedRequestIsStamped() {
+	request, _ := http.NewRequest("DELETE", "https://s3-us-west-1.amazonaws.com/bucket/some/key", nil)
+
+	this.signer.Sign(request)
+
+	this.So(request.Header.Get("X-Amz-Date"), should.NotBeBlank)
+	this.So(request.Header.Get("X-Amz-Content-Sha256"), should.Equal, emptyPayloadDigest)
+	this.So(request.Header.Get("Authorization"), should.StartWith, "AWS4-HMAC-SHA256 Credential=access/")
+}
+
+func (this *SignerFixture) buildRequest(method string, options ...s3.Option) *http.Request {
+	options = append(options,
+		s3.Credentials("access", "secret"),
+		s3.StorageAddress(urlParsed("https://bucket.s3-us-west-1.amazonaws.com/")),
+		s3.Key("/some path/to the/document.json"),
+		s3.Timestamp(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)))
+
+	request, _ := s3.NewRequest(method, options...)
+	return request
+}
@@ -9,12 +9,16 @@ import (
 type ReadWriter struct {
 	*Reader
 	*Writer
+	*Lister
+	*Deleter
 }
 
-func NewStorage(address *url.URL, accessKey, secretKey string, client persist.HTTPClient) persist.ReadWriter {
+func NewStorage(address *url.URL, accessKey, secretKey string, client persist.HTTPClient, options ...Option) persist.ReadWriter {
 	return &ReadWriter{
-		Reader: NewReader(address, accessKey, secretKey, client),
-		Writer: NewWriter(address, accessKey, secretKey, client),
+		Reader:  NewReader(address, accessKey, secretKey, client, options...),
+		Writer:  NewWriter(address, accessKey, secretKey, client, options...),
+		Lister:  NewLister(address, accessKey, secretKey, client, options...),
+		Deleter: NewDeleter(address, accessKey, secretKey, client, options...),
 	}
 }
 
@@ -17,27 +17,59 @@ import (
 )
 
 type Writer struct {
-	credentials s3.Option
+	credentials persist.CredentialsProvider
 	storage     s3.Option
 	client      persist.HTTPClient
+	cipher      persist.Cipher
+	encryption  Encryption
+	signer      signer
+	keyspace    persist.Keyspace
+	cache       persist.ReadCache
 }
 
-func NewWriter(storage *url.URL, accessKey, secretKey string, client persist.HTTPClient) *Writer {
+func NewWriter(storage *url.URL, accessKey, secretKey string, client persist.HTTPClient, options ...Option) *Writer {
+	config := newConfiguration(accessKey, secretKey, options)
+	location := newLocation(storage)
 	return &Writer{
-		credentials: s3.Credentials(accessKey, secretKey),
-		storage:     s3.StorageAddress(storage),
+		credentials: config.credentials,
+		storage:     location.option(),
 		client:      client,
+		cipher:      config.cipher,
+		encryption:  config.encryption,
+		signer:      newSigner(storage, accessKey, secretKey),
+		keyspace:    config.keyspace(location),
+		cache:       config.cache,
 	}
 }
 
 func (this *Writer) Write(document projector.Document) error {
-	body := this.serialize(document)
+	return this.write(document, this.serialize(document), this.contentType())
+}
+
+// WriteStored writes the body as given, described as it was when read.
+func (this *Writer) WriteStored(document projector.Document, stored persist.StoredDocument) error {
+	return this.write(document, stored.Body, s3.CompositeOption(
+		s3.ConditionalOption(s3.ContentType(stored.ContentType), len(stored.ContentType) > 0),
+		s3.ConditionalOption(s3.ContentEncoding(stored.ContentEncoding), len(stored.ContentEncoding) > 0)))
+}
+
+func (this *Writer) write(document projector.Document, body []byte, contentType s3.Option) error {
+	credentials, signer, err := authorize(this.credentials, this.signer)
+	if err != nil {
+		return err
+	}
+
+	key := this.keyspace.Key(document)
 	checksum := this.md5Checksum(body)
-	request := this.buildRequest(document.Path(), body, checksum)
+	request := this.buildRequest(key, body, checksum, contentType, credentials, signer, document.Version())
 	response, err := this.client.Do(request)
 
-	if etag, err := this.handleResponse(response, err); err == nil {
+	if etag, err := this.handleResponse(request, response, err); err == nil {
 		document.SetVersion(etag)
+		this.store(key, body, etag)
+	} else if err == persist.ErrConcurrentWrite {
+		log.Printf("[INFO] Document on remote storage has changed '%s'\n", document.Path())
+		return err
 	} else {
 		return err
 	}
@@ -45,6 +77,13 @@ func (this *Writer) Write(document projector.Document) error {
 	return nil
 }
 
+// store keeps the body just written, which saves reading it again; it's the same body S3 will give back.
+func (this *Writer) store(key string, body []byte, etag interface{}) {
+	if validator, _ := etag.(string); this.cache != nil && len(validator) > 0 {
+		this.cache.Store(key, persist.CachedDocument{Body: body, Version: validator, Validator: validator})
+	}
+}
+
 func (this *Writer) serialize(document projector.Document) []byte {
 	buffer := bytes.NewBuffer([]byte{})
 	gzipWriter, _ := gzip.NewWriterLevel(buffer, gzip.BestCompression)
@@ -55,7 +94,19 @@ func (this *Writer) serialize(document projector.Document) []byte {
 	}
 
 	_ = gzipWriter.Close()
-	return buffer.Bytes()
+	return this.encrypt(buffer.Bytes())
+}
+func (this *Writer) encrypt(body []byte) []byte {
+	if this.cipher == nil {
+		return body
+	}
+
+	encrypted, err := this.cipher.Encrypt(body)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return encrypted
 }
 
 func (this *Writer) md5Checksum(body []byte) string {
@@ -63,29 +114,43 @@ func (this *Writer) md5Checksum(body []byte) string {
 	return base64.StdEncoding.EncodeToString(sum[:])
 }
 
-func (this *Writer) buildRequest(path string, body []byte, checksum string) *http.Request {
+func (this *Writer) buildRequest(
+	key string, body []byte, checksum string, contentType, credentials s3.Option, signer signer, version interface{},
+) *http.Request {
 	request, err := s3.NewRequest(
 		s3.PUT,
-		this.credentials,
+		credentials,
 		this.storage,
-		s3.Key(path),
+		s3.Key(key),
 		s3.ContentBytes(body),
-		s3.ContentType("application/json"),
-		s3.ContentEncoding("gzip"),
+		contentType,
 		s3.ContentMD5(checksum),
-		s3.ServerSideEncryption(s3.ServerSideEncryptionAES256),
+		this.encryption.option(),
+		s3.ConditionalOption(s3.IfNoneMatch("*"), version == persist.Absent),
 	)
 	if err != nil {
 		log.Panic(err)
 	}
+	if etag, _ := version.(string); len(etag) > 0 {
+		request.Header.Set("If-Match", etag) // S3 gives back 412 when the object has since changed
+	}
+	this.encryption.apply(request, signer)
 	return request
 }
 
+func (this *Writer) contentType() s3.Option {
+	if this.cipher != nil {
+		return s3.ContentType("application/octet-stream")
+	}
+
+	return s3.CompositeOption(s3.ContentType("application/json"), s3.ContentEncoding("gzip"))
+}
+
 // handleResponse handles error response, which technically, shouldn't happen
 // because the inner client should be handling retry indefinitely, until the service
 // response. This is here merely for the sake of completeness, and to bullet-proof
 // the software in case the behavior of the inner client changes in the future.
-func (this *Writer) handleResponse(response *http.Response, err error) (interface{}, error) {
+func (this *Writer) handleResponse(request *http.Request, response *http.Response, err error) (interface{}, error) {
 	if err != nil {
 		log.Panic(err)
 		return nil, err
@@ -93,6 +158,10 @@ func (this *Writer) handleResponse(response *http.Response, err error) (interfac
 
 	defer func() { _ = response.Body.Close() }()
 
+	if response.StatusCode == http.StatusPreconditionFailed || isConditional(request) && rejected(response) {
+		return nil, persist.ErrConcurrentWrite
+	}
+
 	if response.StatusCode != http.StatusOK {
 		log.Panic(fmt.Errorf("Non-200 HTTP Status Code: %d %s", response.StatusCode, response.Status))
 		return nil, err
@@ -100,3 +169,15 @@ func (this *Writer) handleResponse(response *http.Response, err error) (interfac
 
 	return response.Header.Get("ETag"), nil
 }
+
+// isConditional reports whether the write was conditional upon the stored object, whether upon its
+// version (If-Match) or its absence (If-None-Match).
+func isConditional(request *http.Request) bool {
+	return len(request.Header.Get("If-Match")) > 0 || len(request.Header.Get("If-None-Match")) > 0
+}
+
+// rejected reports whether S3 turned away a conditional write as upon a version no longer stored (404) or
+// as conflicting with another in flight (409), neither of which succeeds when tried again.
+func rejected(response *http.Response) bool {
+	return response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusConflict
+}
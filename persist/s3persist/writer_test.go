@@ -13,6 +13,8 @@ import (
 	"github.com/smartystreets/assertions/should"
 	"github.com/smartystreets/gunit"
 	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist"
+	"github.com/smartystreets/projector/persist/readcache"
 )
 
 func TestWriterFixture(t *testing.T) {
@@ -56,6 +58,139 @@ func decodeBody(body []byte) string {
 
 // /////////////////////////////////////////////////////////////////
 
+func (this *WriterFixture) TestStoredDocumentWrittenAsGiven() {
+	stored := persist.StoredDocument{Body: []byte("sealed"), ContentType: "application/json", ContentEncoding: "gzip"}
+
+	err := this.writer.WriteStored(writableDocument, stored)
+
+	body, _ := ioutil.ReadAll(this.client.received.Body)
+	this.So(err, should.BeNil)
+	this.So(string(body), should.Equal, "sealed")
+	this.So(this.client.received.Header.Get("Content-Type"), should.Equal, "application/json")
+	this.So(this.client.received.Header.Get("Content-Encoding"), should.Equal, "gzip")
+	this.So(this.client.received.Header.Get("If-Match"), should.Equal, "etag")
+}
+
+// /////////////////////////////////////////////////////////////////
+
+func (this *WriterFixture) TestEncryptedDocumentIsOpaque() {
+	this.writer = this.newWriter(EncryptWith(&FakeCipher{}))
+
+	_ = this.writer.Write(writableDocument)
+
+	body, _ := ioutil.ReadAll(this.client.received.Body)
+	this.So(string(body), should.StartWith, "encrypted:")
+	this.So(decodeBody(body[len("encrypted:"):]), should.Equal, `{"Message":"Hello, World!"}`)
+	this.So(this.client.received.Header.Get("Content-Encoding"), should.BeBlank)
+	this.So(this.client.received.Header.Get("Content-Type"), should.Equal, "application/octet-stream")
+}
+
+// /////////////////////////////////////////////////////////////////
+
+func (this *WriterFixture) TestKMSEncryptionRequested() {
+	this.writer = this.newWriter(ServerSideEncryption(EncryptionKMS("key-id")))
+
+	_ = this.writer.Write(writableDocument)
+
+	headers := this.client.received.Header
+	this.So(headers.Get("X-Amz-Server-Side-Encryption"), should.Equal, "aws:kms")
+	this.So(headers.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"), should.Equal, "key-id")
+	this.So(headers.Get("Authorization"), should.ContainSubstring, "x-amz-server-side-encryption-aws-kms-key-id")
+}
+func (this *WriterFixture) TestCustomerKeyEncryptionRequested() {
+	this.writer = this.newWriter(ServerSideEncryption(EncryptionCustomerKey([]byte("0123456789abcdef0123456789abcdef"))))
+
+	_ = this.writer.Write(writableDocument)
+
+	headers := this.client.received.Header
+	this.So(headers.Get("X-Amz-Server-Side-Encryption"), should.BeBlank)
+	this.So(headers.Get("X-Amz-Server-Side-Encryption-Customer-Algorithm"), should.Equal, "AES256")
+	this.So(headers.Get("X-Amz-Server-Side-Encryption-Customer-Key"), should.Equal, "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	this.So(headers.Get("X-Amz-Server-Side-Encryption-Customer-Key-Md5"), should.NotBeBlank)
+	this.So(headers.Get("Authorization"), should.ContainSubstring, "x-amz-server-side-encryption-customer-key-md5")
+}
+func (this *WriterFixture) TestServerSideEncryptionOmitted() {
+	this.writer = this.newWriter(ServerSideEncryption(EncryptionNone))
+
+	_ = this.writer.Write(writableDocument)
+
+	this.So(this.client.received.Header.Get("X-Amz-Server-Side-Encryption"), should.BeBlank)
+	this.So(this.client.received.Header.Get("Authorization"), should.NotContainSubstring, "encryption")
+}
+func (this *WriterFixture) TestPathPrefixAndNamespacePrecedeDocumentPath() {
+	this.writer = this.newWriter(PathPrefix("projections"), Namespace("/staging/"))
+
+	_ = this.writer.Write(writableDocument)
+
+	this.So(this.client.received.URL.Path, should.Equal, "/bucket/projections/staging/bucket/this/is/the/path.json")
+}
+func (this *WriterFixture) TestKeysMappedWithinPathPrefix() {
+	mapper, _ := persist.NewTemplateKeyMapper("{environment}/{type}/{path}", "staging")
+	this.writer = this.newWriter(PathPrefix("projections"), MapKeys(mapper))
+
+	_ = this.writer.Write(writableDocument)
+
+	this.So(this.client.received.URL.Path, should.Equal, "/bucket/projections/staging/DocumentForWriting/bucket/this/is/the/path.json")
+}
+func (this *WriterFixture) newWriter(options ...Option) *Writer {
+	address := urlParsed("https://bucket.s3-us-west-1.amazonaws.com/")
+	return NewWriter(address, "access", "secret", this.client, options...)
+}
+
+func (this *WriterFixture) TestWrittenDocumentCached() {
+	cache := readcache.NewMemory(1024)
+	this.writer = this.newWriter(CacheReads(cache))
+
+	_ = this.writer.Write(writableDocument)
+
+	cached, found := cache.Load("bucket/this/is/the/path.json")
+	this.So(found, should.BeTrue)
+	this.So(decodeBody(cached.Body), should.Equal, `{"Message":"Hello, World!"}`)
+	this.So(cached.Validator, should.Equal, "etag-here")
+}
+func (this *WriterFixture) TestWriteConditionalUponVersion() {
+	_ = this.writer.Write(writableDocument)
+
+	this.So(this.client.received.Header.Get("If-Match"), should.Equal, "etag")
+}
+func (this *WriterFixture) TestAbsentDocumentWrittenOnlyIfStillAbsent() {
+	_ = this.writer.Write(&DocumentForWriting{Message: "Hello, World!", version: persist.Absent})
+
+	this.So(this.client.received.Header.Get("If-None-Match"), should.Equal, "*")
+	this.So(this.client.received.Header.Get("If-Match"), should.BeBlank)
+}
+func (this *WriterFixture) TestUnversionedDocumentWrittenUnconditionally() {
+	_ = this.writer.Write(&DocumentForWriting{Message: "Hello, World!", version: nil})
+
+	this.So(this.client.received.Header.Get("If-None-Match"), should.BeBlank)
+	this.So(this.client.received.Header.Get("If-Match"), should.BeBlank)
+}
+
+func (this *WriterFixture) TestChangedDocumentIsConcurrentWrite() {
+	this.client.statusCode = http.StatusPreconditionFailed
+
+	err := this.writer.Write(writableDocument)
+
+	this.So(err, should.Equal, persist.ErrConcurrentWrite)
+}
+
+func (this *WriterFixture) TestConditionalWriteOfObjectNoLongerStoredIsConcurrentWrite() {
+	this.client.statusCode = http.StatusNotFound
+
+	err := this.writer.Write(writableDocument)
+
+	this.So(err, should.Equal, persist.ErrConcurrentWrite)
+}
+func (this *WriterFixture) TestConflictingConditionalWriteIsConcurrentWrite() {
+	this.client.statusCode = http.StatusConflict
+
+	err := this.writer.Write(&DocumentForWriting{Message: "Hello, World!", version: persist.Absent})
+
+	this.So(err, should.Equal, persist.ErrConcurrentWrite)
+}
+
+// /////////////////////////////////////////////////////////////////
+
 func (this *WriterFixture) TestDocumentWithIncompatibleFieldCausesPanicUponSerialization() {
 	action := func() { _ = this.writer.Write(badJSONDocument) }
 	this.So(action, should.PanicWith, "json: unsupported type: chan int")
@@ -111,6 +246,17 @@ func (this *FakeHTTPClientForWriting) Do(request *http.Request) (*http.Response,
 
 // ///////////////////////////////////////////////////////////////
 
+type FakeCipher struct{}
+
+func (this *FakeCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	return append([]byte("encrypted:"), plaintext...), nil
+}
+func (this *FakeCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	return bytes.TrimPrefix(ciphertext, []byte("encrypted:")), nil
+}
+
+// ///////////////////////////////////////////////////////////////
+
 type FakeBody struct{ closed int }
 
 func (this *FakeBody) Read([]byte) (int, error) { return 0, nil }
@@ -118,16 +264,19 @@ func (this *FakeBody) Close() error             { this.closed++; return nil }
 
 // ///////////////////////////////////////////////////////////////
 
-var writableDocument = &DocumentForWriting{Message: "Hello, World!"}
+var writableDocument = &DocumentForWriting{Message: "Hello, World!", version: "etag"}
 
-type DocumentForWriting struct{ Message string }
+type DocumentForWriting struct {
+	Message string
+	version interface{}
+}
 
 func (this *DocumentForWriting) Lapse(now time.Time) (next projector.Document) { return this }
 func (this *DocumentForWriting) Apply(message interface{}) bool                { return false }
 func (this *DocumentForWriting) Path() string                                  { return "/bucket/this/is/the/path.json" }
 func (this *DocumentForWriting) Reset()                                        {}
 func (this *DocumentForWriting) SetVersion(interface{})                        {}
-func (this *DocumentForWriting) Version() interface{}                          { return "etag" }
+func (this *DocumentForWriting) Version() interface{}                          { return this.version }
 
 // ///////////////////////////////////////////////////////////////
 
@@ -0,0 +1,114 @@
+package shadowpersist
+
+import (
+	"log"
+	"path"
+
+	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist"
+)
+
+// ReadWriter reads documents from production but diverts every write (and removal) away from it, to a shadow
+// backend, beneath a shadow prefix, or nowhere. Shadow writes are unconditional, detached from production's versions.
+type ReadWriter struct {
+	persist.ReadWriter
+
+	shadow   persist.ReadWriter
+	prefix   string
+	recorder Recorder
+}
+
+// NewReadWriter discards writes until a shadow backend or prefix is specified.
+func NewReadWriter(production persist.ReadWriter) *ReadWriter {
+	return &ReadWriter{ReadWriter: production}
+}
+
+// WithShadow diverts writes to the backend given.
+func (this *ReadWriter) WithShadow(shadow persist.ReadWriter) *ReadWriter {
+	this.shadow = shadow
+	return this
+}
+
+// WithPrefix diverts writes beneath the prefix, within the shadow backend if any, otherwise within production.
+func (this *ReadWriter) WithPrefix(prefix string) *ReadWriter {
+	this.prefix = prefix
+	return this
+}
+
+// WithRecorder compares each document written or removed with production, recording any difference.
+func (this *ReadWriter) WithRecorder(recorder Recorder) *ReadWriter {
+	this.recorder = recorder
+	return this
+}
+
+func (this *ReadWriter) Name() string {
+	return this.ReadWriter.Name() + " (shadowed)"
+}
+
+func (this *ReadWriter) Write(document projector.Document) error {
+	this.record(document, false)
+
+	if storage := this.target(); storage != nil {
+		return storage.Write(persist.Detach(document, this.path(document)))
+	}
+
+	return nil
+}
+func (this *ReadWriter) Delete(document projector.Document) error {
+	this.record(document, true)
+
+	storage := this.target()
+	if storage == nil {
+		return nil
+	}
+
+	if deleter, ok := storage.(persist.Deleter); ok {
+		return deleter.Delete(persist.Detach(document, this.path(document)))
+	}
+
+	log.Printf("[WARN] Shadow storage [%s] is unable to delete document [%s].\n", storage.Name(), this.path(document))
+	return nil
+}
+
+func (this *ReadWriter) target() persist.ReadWriter {
+	if this.shadow != nil {
+		return this.shadow
+	} else if len(this.prefix) > 0 {
+		return this.ReadWriter
+	} else {
+		return nil
+	}
+}
+func (this *ReadWriter) path(document projector.Document) string {
+	if len(this.prefix) == 0 {
+		return document.Path()
+	}
+
+	return path.Join("/", this.prefix, document.Path())
+}
+
+func (this *ReadWriter) record(document projector.Document, removed bool) {
+	if this.recorder == nil {
+		return
+	}
+
+	production := persist.RawDocumentOf(document)
+	if err := this.ReadWriter.Read(production); err != nil {
+		log.Printf("[WARN] Unable to read production document [%s] for comparison: %s\n", document.Path(), err)
+		return
+	}
+
+	difference := Difference{Path: document.Path(), Production: production.Body()}
+	if !removed {
+		snapshot, err := persist.Snapshot(document.Path(), document)
+		if err != nil {
+			log.Printf("[WARN] Unable to serialize shadowed document [%s] for comparison: %s\n", document.Path(), err)
+			return
+		}
+		difference.Shadow = snapshot.Body()
+	}
+
+	if !equivalent(difference.Production, difference.Shadow) {
+		this.recorder.Record(difference)
+	}
+}
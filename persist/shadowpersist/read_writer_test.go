@@ -0,0 +1,115 @@
+package shadowpersist
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist/persisttest"
+)
+
+func TestReadWriterFixture(t *testing.T) {
+	gunit.Run(new(ReadWriterFixture), t)
+}
+
+type ReadWriterFixture struct {
+	*gunit.Fixture
+
+	production *persisttest.Storage
+	shadow     *persisttest.Storage
+	recorder   *FakeRecorder
+	document   *FakeDocument
+}
+
+func (this *ReadWriterFixture) Setup() {
+	this.production = persisttest.NewStorage("production")
+	this.production.Put("/totals.json", `{"Total": 1}`)
+	this.shadow = persisttest.NewStorage("shadow")
+	this.recorder = &FakeRecorder{}
+	this.document = &FakeDocument{Total: 2}
+	this.document.SetVersion("production-version")
+}
+
+func (this *ReadWriterFixture) TestReadsFromProduction() {
+	document := &FakeDocument{}
+
+	_ = NewReadWriter(this.production).WithShadow(this.shadow).Read(document)
+
+	this.So(document.Total, should.Equal, 1)
+}
+
+func (this *ReadWriterFixture) TestWritesDivertedToShadowBackend() {
+	err := NewReadWriter(this.production).WithShadow(this.shadow).Write(this.document)
+
+	this.So(err, should.BeNil)
+	this.So(this.shadow.Bodies["/totals.json"], should.Equal, `{"Total":2}`)
+	this.So(this.shadow.WrittenVersions, should.Resemble, []interface{}{nil})
+	this.So(this.production.Bodies["/totals.json"], should.Equal, `{"Total": 1}`)
+	this.So(this.document.Version(), should.Equal, "production-version")
+}
+
+func (this *ReadWriterFixture) TestWritesDivertedBeneathPrefixOfProduction() {
+	_ = NewReadWriter(this.production).WithPrefix("shadow").Write(this.document)
+
+	this.So(this.production.Bodies["/shadow/totals.json"], should.Equal, `{"Total":2}`)
+	this.So(this.production.Bodies["/totals.json"], should.Equal, `{"Total": 1}`)
+}
+
+func (this *ReadWriterFixture) TestWritesDiscardedButDifferencesRecorded() {
+	err := NewReadWriter(this.production).WithRecorder(this.recorder).Write(this.document)
+
+	this.So(err, should.BeNil)
+	this.So(this.production.Writes, should.Equal, 0)
+	this.So(this.recorder.differences, should.Resemble, []Difference{
+		{Path: "/totals.json", Production: json.RawMessage(`{"Total": 1}`), Shadow: json.RawMessage(`{"Total":2}`)},
+	})
+}
+
+func (this *ReadWriterFixture) TestEquivalentDocumentsNotRecorded() {
+	this.document.Total = 1
+
+	_ = NewReadWriter(this.production).WithRecorder(this.recorder).Write(this.document)
+
+	this.So(this.recorder.differences, should.BeEmpty)
+}
+
+func (this *ReadWriterFixture) TestNewDocumentsRecorded() {
+	delete(this.production.Bodies, "/totals.json")
+
+	_ = NewReadWriter(this.production).WithRecorder(this.recorder).Write(this.document)
+
+	this.So(this.recorder.differences, should.HaveLength, 1)
+	this.So(this.recorder.differences[0].Production, should.BeEmpty)
+}
+
+func (this *ReadWriterFixture) TestRemovalsDivertedAndRecorded() {
+	this.shadow.Put("/totals.json", `{}`)
+
+	err := NewReadWriter(this.production).WithShadow(this.shadow).WithRecorder(this.recorder).Delete(this.document)
+
+	this.So(err, should.BeNil)
+	this.So(this.shadow.Bodies, should.BeEmpty)
+	this.So(this.production.Bodies, should.ContainKey, "/totals.json")
+	this.So(this.recorder.differences[0].Shadow, should.BeEmpty)
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+type FakeRecorder struct{ differences []Difference }
+
+func (this *FakeRecorder) Record(difference Difference) {
+	this.differences = append(this.differences, difference)
+}
+
+type FakeDocument struct {
+	projector.VersionInfo
+
+	Total int
+}
+
+func (this *FakeDocument) Lapse(now time.Time) projector.Document { return this }
+func (this *FakeDocument) Apply(message interface{}) bool         { return false }
+func (this *FakeDocument) Path() string                           { return "/totals.json" }
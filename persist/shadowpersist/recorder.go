@@ -0,0 +1,53 @@
+package shadowpersist
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+)
+
+// Recorder receives each difference between a document as it would have been written
+// and as it stands in production.
+type Recorder interface {
+	Record(Difference)
+}
+
+// Difference holds the JSON of a document in production and as it would have been written (shadowed),
+// either of which is empty when the document doesn't exist there (or would have been removed).
+type Difference struct {
+	Path       string
+	Production json.RawMessage
+	Shadow     json.RawMessage
+}
+
+// LogRecorder logs each difference.
+type LogRecorder struct{}
+
+func NewLogRecorder() *LogRecorder {
+	return &LogRecorder{}
+}
+
+func (this *LogRecorder) Record(difference Difference) {
+	log.Printf("[INFO] Shadowed document [%s] differs from production:\n- production: %s\n- shadow:     %s\n",
+		difference.Path, describe(difference.Production), describe(difference.Shadow))
+}
+func describe(body json.RawMessage) string {
+	if len(body) == 0 {
+		return "(none)"
+	}
+	return string(body)
+}
+
+// equivalent compares JSON structurally, disregarding formatting and the order of fields.
+func equivalent(production, shadow json.RawMessage) bool {
+	if len(production) == 0 || len(shadow) == 0 {
+		return len(production) == len(shadow)
+	}
+
+	var left, right interface{}
+	if json.Unmarshal(production, &left) != nil || json.Unmarshal(shadow, &right) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(left, right)
+}
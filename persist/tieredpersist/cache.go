@@ -0,0 +1,138 @@
+package tieredpersist
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist"
+)
+
+// Cache reads documents through a faster cache storage in front of a slower backend, keeping each along with its
+// version in the backend, such that writes remain conditional upon it. A failed write evicts the cached document.
+type Cache struct {
+	persist.ReadWriter
+
+	cache persist.ReadWriter
+}
+
+func NewCache(cache, backend persist.ReadWriter) *Cache {
+	return &Cache{ReadWriter: backend, cache: cache}
+}
+
+func (this *Cache) Name() string {
+	return this.ReadWriter.Name() + " (cached by " + this.cache.Name() + ")"
+}
+
+func (this *Cache) ReadPanic(document projector.Document) {
+	if err := this.Read(document); err != nil {
+		log.Panic(err)
+	}
+}
+func (this *Cache) Read(document projector.Document) error {
+	if this.readCache(document) {
+		return nil
+	}
+
+	if err := this.ReadWriter.Read(document); err != nil {
+		return err
+	}
+
+	if document.Version() != nil {
+		this.store(document)
+	}
+
+	return nil
+}
+func (this *Cache) readCache(document projector.Document) bool {
+	entry := newCacheEntry(document.Path())
+	if err := this.cache.Read(entry); err != nil {
+		log.Printf("[WARN] Unable to read document [%s] from cache [%s]: %s\n", document.Path(), this.cache.Name(), err)
+		return false
+	} else if len(entry.contents.Document) == 0 || entry.contents.Version == nil {
+		return false
+	}
+
+	if err := json.Unmarshal(entry.contents.Document, document); err != nil {
+		log.Printf("[WARN] Unable to decode document [%s] from cache [%s]: %s\n", document.Path(), this.cache.Name(), err)
+		document.Reset()
+		return false
+	}
+
+	document.SetVersion(entry.contents.Version)
+	return true
+}
+
+func (this *Cache) Write(document projector.Document) error {
+	if err := this.ReadWriter.Write(document); err != nil {
+		this.evict(document)
+		return err
+	}
+
+	this.store(document)
+	return nil
+}
+func (this *Cache) Delete(document projector.Document) error {
+	err := remove(this.ReadWriter, document)
+	this.evict(document)
+	return err
+}
+func (this *Cache) List(prefix string, visit func(persist.DocumentInfo) error) error {
+	return list(this.ReadWriter, prefix, visit)
+}
+
+func (this *Cache) store(document projector.Document) {
+	body, err := json.Marshal(document)
+	if err != nil {
+		log.Printf("[WARN] Unable to encode document [%s] for cache [%s]: %s\n", document.Path(), this.cache.Name(), err)
+		return
+	}
+
+	entry := newCacheEntry(document.Path())
+	entry.contents.Version, entry.contents.Document = document.Version(), body
+	if err = this.cache.Write(entry); err != nil {
+		log.Printf("[WARN] Unable to write document [%s] to cache [%s]: %s\n", document.Path(), this.cache.Name(), err)
+	}
+}
+
+// evict removes the document from the cache or, when the cache can't remove documents, empties its entry.
+func (this *Cache) evict(document projector.Document) {
+	entry := newCacheEntry(document.Path())
+
+	var err error
+	if deleter, ok := this.cache.(persist.Deleter); ok {
+		err = deleter.Delete(entry)
+	} else {
+		err = this.cache.Write(entry)
+	}
+
+	if err != nil {
+		log.Printf("[WARN] Unable to evict document [%s] from cache [%s]: %s\n", document.Path(), this.cache.Name(), err)
+	}
+}
+
+// cacheEntry holds a document within the cache together with its version in the backend. The version
+// of the entry itself, within the cache, is disregarded: entries are always written unconditionally.
+type cacheEntry struct {
+	path     string
+	contents cacheContents
+}
+type cacheContents struct {
+	Version  interface{}     `json:"version"`
+	Document json.RawMessage `json:"document,omitempty"`
+}
+
+func newCacheEntry(path string) *cacheEntry {
+	return &cacheEntry{path: path}
+}
+
+func (this *cacheEntry) Lapse(time.Time) projector.Document { return this }
+func (this *cacheEntry) Apply(interface{}) bool             { return false }
+func (this *cacheEntry) Path() string                       { return this.path }
+func (this *cacheEntry) Reset()                             { this.contents = cacheContents{} }
+func (this *cacheEntry) SetVersion(interface{})             {}
+func (this *cacheEntry) Version() interface{}               { return nil }
+
+func (this *cacheEntry) MarshalJSON() ([]byte, error)    { return json.Marshal(this.contents) }
+func (this *cacheEntry) UnmarshalJSON(body []byte) error { return json.Unmarshal(body, &this.contents) }
@@ -0,0 +1,110 @@
+package tieredpersist
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector/persist"
+	"github.com/smartystreets/projector/persist/persisttest"
+)
+
+func TestCacheFixture(t *testing.T) {
+	gunit.Run(new(CacheFixture), t)
+}
+
+type CacheFixture struct {
+	*gunit.Fixture
+
+	cache   *persisttest.Storage
+	backend *persisttest.Storage
+	tiered  *Cache
+}
+
+func (this *CacheFixture) Setup() {
+	this.cache = persisttest.NewStorage("cache")
+	this.backend = persisttest.NewStorage("backend")
+	this.tiered = NewCache(this.cache, this.backend)
+	this.backend.Put("/document", `{"Value":1}`)
+}
+
+func (this *CacheFixture) TestReadThroughToBackendAndCachedWithBackendVersion() {
+	first := &Document{}
+	_ = this.tiered.Read(first)
+	second := &Document{}
+	err := this.tiered.Read(second)
+
+	this.So(err, should.BeNil)
+	this.So(first.Value, should.Equal, 1)
+	this.So(second.Value, should.Equal, 1)
+	this.So(second.Version(), should.Equal, "backend-1")
+	this.So(this.backend.Reads, should.Equal, 1)
+	this.So(this.cache.Bodies["/document"], should.Equal, `{"version":"backend-1","document":{"Value":1}}`)
+}
+
+func (this *CacheFixture) TestMissingDocumentNotCached() {
+	this.backend.Bodies = map[string]string{}
+
+	_ = this.tiered.Read(&Document{})
+
+	this.So(this.cache.Bodies, should.BeEmpty)
+}
+
+func (this *CacheFixture) TestWriteRefreshesCache() {
+	document := &Document{}
+	_ = this.tiered.Read(document)
+	document.Value = 2
+
+	err := this.tiered.Write(document)
+
+	this.So(err, should.BeNil)
+	this.So(document.Version(), should.Equal, "backend-2")
+	this.So(this.cache.Bodies["/document"], should.Equal, `{"version":"backend-2","document":{"Value":2}}`)
+	this.So(this.cache.WrittenVersions, should.Resemble, []interface{}{nil, nil})
+}
+
+func (this *CacheFixture) TestStaleCacheEvictedByConcurrentWrite() {
+	_ = this.tiered.Read(&Document{})
+	this.backend.Put("/document", `{"Value":5}`) // written elsewhere
+
+	stale := &Document{}
+	_ = this.tiered.Read(stale)
+	stale.Value = 2
+	err := this.tiered.Write(stale)
+	this.So(err, should.Equal, persist.ErrConcurrentWrite)
+	this.So(this.cache.Bodies, should.BeEmpty)
+
+	fresh := &Document{}
+	_ = this.tiered.Read(fresh)
+	this.So(fresh.Value, should.Equal, 5)
+	this.So(fresh.Version(), should.Equal, "backend-2")
+}
+
+func (this *CacheFixture) TestCacheFailureReadsFromBackend() {
+	this.cache.ReadError = errors.New("BOINK!")
+	document := &Document{}
+
+	err := this.tiered.Read(document)
+
+	this.So(err, should.BeNil)
+	this.So(document.Value, should.Equal, 1)
+}
+
+func (this *CacheFixture) TestBackendFailureGivenBack() {
+	this.backend.ReadError = errors.New("BOINK!")
+
+	err := this.tiered.Read(&Document{})
+
+	this.So(err, should.Equal, this.backend.ReadError)
+}
+
+func (this *CacheFixture) TestRemovalEvicts() {
+	_ = this.tiered.Read(&Document{})
+
+	err := this.tiered.Delete(&Document{})
+
+	this.So(err, should.BeNil)
+	this.So(this.backend.Bodies, should.BeEmpty)
+	this.So(this.cache.Bodies, should.BeEmpty)
+}
@@ -0,0 +1,49 @@
+package tieredpersist
+
+import (
+	"log"
+
+	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist"
+)
+
+// Fallback reads each document which the primary storage doesn't have (as opposed to fails to read) from the
+// secondary, and writes only to the primary, where those read from the secondary are created only if absent.
+type Fallback struct {
+	persist.ReadWriter
+
+	secondary persist.ReadWriter
+}
+
+func NewFallback(primary, secondary persist.ReadWriter) *Fallback {
+	return &Fallback{ReadWriter: primary, secondary: secondary}
+}
+
+func (this *Fallback) Name() string {
+	return this.ReadWriter.Name() + " (falling back to " + this.secondary.Name() + ")"
+}
+
+func (this *Fallback) ReadPanic(document projector.Document) {
+	if err := this.Read(document); err != nil {
+		log.Panic(err)
+	}
+}
+func (this *Fallback) Read(document projector.Document) error {
+	if err := this.ReadWriter.Read(document); err != nil || document.Version() != nil {
+		return err
+	}
+
+	document.Reset()
+	if err := this.secondary.Read(persist.Detach(document, document.Path())); err != nil {
+		log.Printf("[WARN] Unable to read document [%s] from [%s]: %s\n", document.Path(), this.secondary.Name(), err)
+		return err
+	}
+
+	return nil
+}
+func (this *Fallback) Delete(document projector.Document) error {
+	return remove(this.ReadWriter, document)
+}
+func (this *Fallback) List(prefix string, visit func(persist.DocumentInfo) error) error {
+	return list(this.ReadWriter, prefix, visit)
+}
@@ -0,0 +1,83 @@
+package tieredpersist
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector/persist/persisttest"
+)
+
+func TestFallbackFixture(t *testing.T) {
+	gunit.Run(new(FallbackFixture), t)
+}
+
+type FallbackFixture struct {
+	*gunit.Fixture
+
+	primary   *persisttest.Storage
+	secondary *persisttest.Storage
+	fallback  *Fallback
+	document  *Document
+}
+
+func (this *FallbackFixture) Setup() {
+	this.primary = persisttest.NewStorage("primary")
+	this.secondary = persisttest.NewStorage("secondary")
+	this.fallback = NewFallback(this.primary, this.secondary)
+	this.document = &Document{}
+}
+
+func (this *FallbackFixture) TestDocumentFoundInPrimary() {
+	this.primary.Put("/document", `{"Value":1}`)
+	this.secondary.Put("/document", `{"Value":2}`)
+
+	err := this.fallback.Read(this.document)
+
+	this.So(err, should.BeNil)
+	this.So(this.document.Value, should.Equal, 1)
+	this.So(this.document.Version(), should.Equal, "primary-1")
+	this.So(this.secondary.Reads, should.Equal, 0)
+}
+
+func (this *FallbackFixture) TestDocumentMissingFromPrimaryReadFromSecondaryWithoutVersion() {
+	this.secondary.Put("/document", `{"Value":2}`)
+
+	err := this.fallback.Read(this.document)
+
+	this.So(err, should.BeNil)
+	this.So(this.document.Value, should.Equal, 2)
+	this.So(this.document.Version(), should.BeNil)
+}
+
+func (this *FallbackFixture) TestPrimaryFailureGivenBackWithoutFallingBack() {
+	this.primary.ReadError = errors.New("BOINK!")
+	this.secondary.Put("/document", `{"Value":2}`)
+
+	err := this.fallback.Read(this.document)
+
+	this.So(err, should.Equal, this.primary.ReadError)
+	this.So(this.document.Value, should.Equal, 0)
+	this.So(this.secondary.Reads, should.Equal, 0)
+}
+
+func (this *FallbackFixture) TestSecondaryFailureGivenBack() {
+	this.secondary.ReadError = errors.New("BOINK!")
+
+	err := this.fallback.Read(this.document)
+
+	this.So(err, should.Equal, this.secondary.ReadError)
+}
+
+func (this *FallbackFixture) TestWrittenOnlyToPrimary() {
+	this.secondary.Put("/document", `{"Value":2}`)
+	_ = this.fallback.Read(this.document)
+	this.document.Value = 3
+
+	err := this.fallback.Write(this.document)
+
+	this.So(err, should.BeNil)
+	this.So(this.primary.Bodies["/document"], should.Equal, `{"Value":3}`)
+	this.So(this.secondary.Bodies["/document"], should.Equal, `{"Value":2}`)
+}
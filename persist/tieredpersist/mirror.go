@@ -0,0 +1,55 @@
+package tieredpersist
+
+import (
+	"log"
+
+	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist"
+)
+
+// Mirror reads documents from the primary storage and writes (and removes) them there, repeating each
+// successful write with every secondary storage. The primary alone decides whether a write succeeds:
+// secondaries are written unconditionally, on a best-effort basis, with any failure only being logged.
+type Mirror struct {
+	persist.ReadWriter
+
+	secondaries []persist.ReadWriter
+}
+
+func NewMirror(primary persist.ReadWriter, secondaries ...persist.ReadWriter) *Mirror {
+	return &Mirror{ReadWriter: primary, secondaries: secondaries}
+}
+
+func (this *Mirror) Name() string {
+	return this.ReadWriter.Name() + " (mirrored)"
+}
+
+func (this *Mirror) Write(document projector.Document) error {
+	if err := this.ReadWriter.Write(document); err != nil {
+		return err
+	}
+
+	for _, secondary := range this.secondaries {
+		if err := secondary.Write(persist.Detach(document, document.Path())); err != nil {
+			log.Printf("[WARN] Unable to mirror document [%s] to [%s]: %s\n", document.Path(), secondary.Name(), err)
+		}
+	}
+
+	return nil
+}
+func (this *Mirror) Delete(document projector.Document) error {
+	if err := remove(this.ReadWriter, document); err != nil {
+		return err
+	}
+
+	for _, secondary := range this.secondaries {
+		if err := remove(secondary, persist.Detach(document, document.Path())); err != nil {
+			log.Printf("[WARN] Unable to remove mirrored document [%s] from [%s]: %s\n", document.Path(), secondary.Name(), err)
+		}
+	}
+
+	return nil
+}
+func (this *Mirror) List(prefix string, visit func(persist.DocumentInfo) error) error {
+	return list(this.ReadWriter, prefix, visit)
+}
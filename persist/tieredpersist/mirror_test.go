@@ -0,0 +1,112 @@
+package tieredpersist
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist"
+	"github.com/smartystreets/projector/persist/persisttest"
+)
+
+func TestMirrorFixture(t *testing.T) {
+	gunit.Run(new(MirrorFixture), t)
+}
+
+type MirrorFixture struct {
+	*gunit.Fixture
+
+	primary   *persisttest.Storage
+	secondary *persisttest.Storage
+	mirror    *Mirror
+}
+
+func (this *MirrorFixture) Setup() {
+	this.primary = persisttest.NewStorage("primary")
+	this.secondary = persisttest.NewStorage("secondary")
+	this.mirror = NewMirror(this.primary, this.secondary)
+}
+
+func (this *MirrorFixture) TestReadFromPrimary() {
+	this.primary.Put("/document", `{"Value":1}`)
+	this.secondary.Put("/document", `{"Value":2}`)
+	document := &Document{}
+
+	err := this.mirror.Read(document)
+
+	this.So(err, should.BeNil)
+	this.So(document.Value, should.Equal, 1)
+	this.So(document.Version(), should.Equal, "primary-1")
+}
+
+func (this *MirrorFixture) TestWriteRepeatedUnconditionallyWithSecondary() {
+	this.primary.Put("/document", `{"Value":1}`)
+	this.secondary.Put("/document", `{"Value":1}`)
+	document := &Document{Value: 2}
+	document.SetVersion("primary-1")
+
+	err := this.mirror.Write(document)
+
+	this.So(err, should.BeNil)
+	this.So(document.Version(), should.Equal, "primary-2")
+	this.So(this.primary.Bodies["/document"], should.Equal, `{"Value":2}`)
+	this.So(this.secondary.Bodies["/document"], should.Equal, `{"Value":2}`)
+	this.So(this.secondary.WrittenVersions, should.Resemble, []interface{}{nil})
+}
+
+func (this *MirrorFixture) TestPrimaryFailureNotMirrored() {
+	this.primary.WriteError = persist.ErrConcurrentWrite
+
+	err := this.mirror.Write(&Document{Value: 2})
+
+	this.So(err, should.Equal, persist.ErrConcurrentWrite)
+	this.So(this.secondary.Bodies, should.BeEmpty)
+}
+
+func (this *MirrorFixture) TestSecondaryFailureDisregarded() {
+	this.secondary.WriteError = errors.New("BOINK!")
+	document := &Document{Value: 2}
+
+	err := this.mirror.Write(document)
+
+	this.So(err, should.BeNil)
+	this.So(document.Version(), should.Equal, "primary-1")
+}
+
+func (this *MirrorFixture) TestRemovalMirrored() {
+	this.primary.Put("/document", `{"Value":1}`)
+	this.secondary.Put("/document", `{"Value":1}`)
+
+	err := this.mirror.Delete(&Document{})
+
+	this.So(err, should.BeNil)
+	this.So(this.primary.Bodies, should.BeEmpty)
+	this.So(this.secondary.Bodies, should.BeEmpty)
+}
+
+func (this *MirrorFixture) TestListedFromPrimary() {
+	this.primary.Put("/a", `{}`)
+	this.secondary.Put("/b", `{}`)
+	var paths []string
+
+	err := this.mirror.List("/", func(info persist.DocumentInfo) error { paths = append(paths, info.Path); return nil })
+
+	this.So(err, should.BeNil)
+	this.So(paths, should.Resemble, []string{"/a"})
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+type Document struct {
+	projector.VersionInfo
+
+	Value int
+}
+
+func (this *Document) Lapse(time.Time) projector.Document { return this }
+func (this *Document) Apply(interface{}) bool             { return false }
+func (this *Document) Path() string                       { return "/document" }
+func (this *Document) Reset()                             { this.Value = 0; this.VersionInfo.Reset() }
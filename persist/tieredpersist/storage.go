@@ -0,0 +1,23 @@
+package tieredpersist
+
+import (
+	"fmt"
+
+	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist"
+)
+
+func list(storage persist.ReadWriter, prefix string, visit func(persist.DocumentInfo) error) error {
+	if lister, ok := storage.(persist.Lister); ok {
+		return lister.List(prefix, visit)
+	}
+
+	return fmt.Errorf("storage [%s] is unable to list documents", storage.Name())
+}
+func remove(storage persist.ReadWriter, document projector.Document) error {
+	if deleter, ok := storage.(persist.Deleter); ok {
+		return deleter.Delete(document)
+	}
+
+	return fmt.Errorf("storage [%s] is unable to delete documents", storage.Name())
+}
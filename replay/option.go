@@ -0,0 +1,53 @@
+package replay
+
+import (
+	"time"
+
+	"github.com/smartystreets/projector/transform"
+)
+
+type Option func(*configuration)
+
+type configuration struct {
+	transform []transform.Option
+	period    time.Duration
+	batchSize int
+	publish   func() error
+	now       func() time.Time
+}
+
+func newConfiguration(options []Option) configuration {
+	config := configuration{period: time.Hour, batchSize: 1024, now: utcNow}
+	for _, option := range options {
+		option(&config)
+	}
+	return config
+}
+
+// Transform configures the transformer, chiefly with the (fresh) documents into which messages are projected.
+func Transform(options ...transform.Option) Option {
+	return func(this *configuration) { this.transform = append(this.transform, options...) }
+}
+
+// Period keeps deliveries from different periods (e.g. hours or days) out of the same batch, such that documents
+// lapse at the timestamps of the deliveries. It should be no longer than the shortest period of any document.
+func Period(period time.Duration) Option {
+	return func(this *configuration) { this.period = period }
+}
+
+// BatchSize limits the number of deliveries projected (and written) at once.
+func BatchSize(size int) Option {
+	return func(this *configuration) { this.batchSize = size }
+}
+
+// Publish is called once every delivery has been replayed, for example to flip an alias to the rebuilt documents.
+func Publish(publish func() error) Option {
+	return func(this *configuration) { this.publish = publish }
+}
+
+// Clock gives the time at which deliveries without a timestamp are projected (by default, the current UTC time).
+func Clock(now func() time.Time) Option {
+	return func(this *configuration) { this.now = now }
+}
+
+func utcNow() time.Time { return time.Now().UTC() }
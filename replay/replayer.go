@@ -0,0 +1,108 @@
+package replay
+
+import (
+	"io"
+	"log"
+	"time"
+
+	"github.com/smartystreets/messaging/v2"
+	"github.com/smartystreets/projector/persist"
+	"github.com/smartystreets/projector/transform"
+)
+
+// Report tallies the work of a replay.
+type Report struct {
+	Deliveries int
+	Batches    int
+	Published  bool
+}
+
+// Replayer rebuilds projections by running the deliveries of a source through the transformer, projecting
+// each batch at the timestamp of its last delivery. The storage (or its path prefix) should be a fresh one
+// such that the rebuilt documents don't disturb those in use until the replay is complete and published.
+type Replayer struct {
+	source      Source
+	transformer transform.Transformer
+	period      time.Duration
+	batchSize   int
+	publish     func() error
+	now         func() time.Time
+	batch       []messaging.Delivery
+}
+
+func New(source Source, storage persist.ReadWriter, options ...Option) *Replayer {
+	config := newConfiguration(options)
+	return &Replayer{
+		source:      source,
+		transformer: transform.NewTransformer(storage, config.transform...),
+		period:      config.period,
+		batchSize:   config.batchSize,
+		publish:     config.publish,
+		now:         config.now,
+	}
+}
+
+// Run replays every delivery of the source and then publishes the result. A failure to read from the source,
+// or to save a document, stops the replay without publishing what was projected so far.
+func (this *Replayer) Run() (report Report, err error) {
+	for {
+		delivery, err := this.source.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return report, err
+		}
+
+		if this.full(delivery) {
+			if err = this.flush(&report); err != nil {
+				return report, err
+			}
+		}
+
+		this.batch = append(this.batch, delivery)
+		report.Deliveries++
+	}
+	if err = this.flush(&report); err != nil {
+		return report, err
+	}
+	log.Printf("[INFO] Replayed %d deliveries in %d batches.\n", report.Deliveries, report.Batches)
+
+	if this.publish == nil {
+		return report, nil
+	} else if err = this.publish(); err != nil {
+		return report, err
+	}
+
+	report.Published = true
+	return report, nil
+}
+func (this *Replayer) full(next messaging.Delivery) bool {
+	if len(this.batch) == 0 {
+		return false
+	} else if this.batchSize > 0 && len(this.batch) >= this.batchSize {
+		return true
+	}
+
+	previous := this.batch[len(this.batch)-1].Timestamp
+	return this.period > 0 && !previous.Truncate(this.period).Equal(next.Timestamp.Truncate(this.period))
+}
+func (this *Replayer) flush(report *Report) error {
+	if len(this.batch) == 0 {
+		return nil
+	}
+
+	if err := this.transformer.Transform(this.clock(), this.batch); err != nil {
+		return err
+	}
+
+	this.batch = this.batch[0:0]
+	report.Batches++
+	return nil
+}
+func (this *Replayer) clock() time.Time {
+	if last := this.batch[len(this.batch)-1].Timestamp; !last.IsZero() {
+		return last
+	}
+
+	return this.now()
+}
@@ -0,0 +1,171 @@
+package replay
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/messaging/v2"
+	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist/persisttest"
+	"github.com/smartystreets/projector/transform"
+)
+
+func TestReplayerFixture(t *testing.T) {
+	gunit.Run(new(ReplayerFixture), t)
+}
+
+type ReplayerFixture struct {
+	*gunit.Fixture
+
+	start    time.Time
+	source   *FakeSource
+	storage  *persisttest.Storage
+	document *FakeDocument
+}
+
+func (this *ReplayerFixture) Setup() {
+	this.start = time.Date(2020, 6, 30, 23, 0, 0, 0, time.UTC)
+	this.source = &FakeSource{}
+	this.storage = persisttest.NewStorage("fake")
+	this.document = &FakeDocument{}
+}
+
+func (this *ReplayerFixture) deliver(message interface{}, offset time.Duration) {
+	this.source.deliveries = append(this.source.deliveries, messaging.Delivery{Message: message, Timestamp: this.start.Add(offset)})
+}
+func (this *ReplayerFixture) replay(options ...Option) (Report, error) {
+	options = append(options, Transform(transform.Documents(this.document)))
+	return New(this.source, this.storage, options...).Run()
+}
+
+func (this *ReplayerFixture) TestDeliveriesProjectedAtTheirTimestamps() {
+	this.deliver(1, 0)
+	this.deliver(2, time.Minute)
+	this.deliver(3, time.Hour+time.Minute)
+
+	report, err := this.replay()
+
+	this.So(err, should.BeNil)
+	this.So(report, should.Resemble, Report{Deliveries: 3, Batches: 2})
+	this.So(this.document.lapses, should.Resemble, []time.Time{this.start.Add(time.Minute), this.start.Add(time.Hour + time.Minute)})
+	this.So(this.document.messages, should.Resemble, []interface{}{1, 2, 3})
+	this.So(this.storage.Writes, should.Equal, 2)
+}
+
+func (this *ReplayerFixture) TestBatchesLimitedInSize() {
+	for i := 0; i < 5; i++ {
+		this.deliver(i, time.Duration(i)*time.Second)
+	}
+
+	report, _ := this.replay(BatchSize(2))
+
+	this.So(report.Batches, should.Equal, 3)
+}
+
+func (this *ReplayerFixture) TestDeliveriesWithoutTimestampProjectedAtClock() {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	this.source.deliveries = []messaging.Delivery{{Message: 1}}
+
+	_, _ = this.replay(Clock(func() time.Time { return now }))
+
+	this.So(this.document.lapses, should.Resemble, []time.Time{now})
+}
+
+func (this *ReplayerFixture) TestPublishedOnceComplete() {
+	this.deliver(1, 0)
+	published := 0
+
+	report, err := this.replay(Publish(func() error { published = this.storage.Writes; return nil }))
+
+	this.So(err, should.BeNil)
+	this.So(report.Published, should.BeTrue)
+	this.So(published, should.Equal, 1)
+}
+
+func (this *ReplayerFixture) TestPublishingFailureGivenBack() {
+	failure := errors.New("BOINK!")
+
+	report, err := this.replay(Publish(func() error { return failure }))
+
+	this.So(err, should.Equal, failure)
+	this.So(report.Published, should.BeFalse)
+}
+
+func (this *ReplayerFixture) TestSourceFailureStopsReplayWithoutPublishing() {
+	this.deliver(1, 0)
+	this.source.err = errors.New("BOINK!")
+	published := false
+
+	report, err := this.replay(Publish(func() error { published = true; return nil }))
+
+	this.So(err, should.Equal, this.source.err)
+	this.So(report.Deliveries, should.Equal, 1)
+	this.So(published, should.BeFalse)
+	this.So(this.storage.Writes, should.Equal, 0)
+}
+
+func (this *ReplayerFixture) TestUnsavedDocumentStopsReplayWithoutPublishing() {
+	this.deliver(1, 0)
+	this.deliver(2, time.Hour)
+	this.storage.WriteError = errors.New("BOINK!")
+	published := false
+
+	report, err := this.replay(Transform(transform.MaxConflicts(1, nil)), Publish(func() error { published = true; return nil }))
+
+	this.So(errors.Is(err, transform.ErrTooManyConflicts), should.BeTrue)
+	this.So(report.Batches, should.Equal, 0)
+	this.So(this.storage.WrittenVersions, should.HaveLength, 1)
+	this.So(published, should.BeFalse)
+}
+
+func (this *ReplayerFixture) TestReplayFromJSONLines() {
+	input := strings.NewReader(`{"message_id":1,"message_type":"number","timestamp":"2020-06-30T23:00:00Z","payload":1}
+{"message_id":2,"message_type":"number","timestamp":"2020-06-30T23:30:00Z","payload":2}`)
+	source := NewJSONLinesSource(input, JSONDecoder(map[string]interface{}{"number": 0}))
+
+	report, _ := New(source, this.storage, Transform(transform.Documents(this.document))).Run()
+
+	this.So(report.Deliveries, should.Equal, 2)
+	this.So(this.document.messages, should.Resemble, []interface{}{1, 2})
+	this.So(this.document.lapses, should.Resemble, []time.Time{this.start.Add(time.Minute * 30)})
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+type FakeSource struct {
+	deliveries []messaging.Delivery
+	err        error
+}
+
+func (this *FakeSource) Next() (messaging.Delivery, error) {
+	if len(this.deliveries) > 0 {
+		delivery := this.deliveries[0]
+		this.deliveries = this.deliveries[1:]
+		return delivery, nil
+	} else if this.err != nil {
+		return messaging.Delivery{}, this.err
+	}
+	return messaging.Delivery{}, io.EOF
+}
+
+type FakeDocument struct {
+	projector.VersionInfo
+
+	lapses   []time.Time
+	messages []interface{}
+}
+
+func (this *FakeDocument) Lapse(now time.Time) projector.Document {
+	this.lapses = append(this.lapses, now)
+	return this
+}
+func (this *FakeDocument) Apply(message interface{}) bool {
+	this.messages = append(this.messages, message)
+	return true
+}
+func (this *FakeDocument) Path() string { return "/replayed.json" }
@@ -0,0 +1,84 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/smartystreets/messaging/v2"
+)
+
+// Source gives the deliveries to be replayed in the order they were first delivered,
+// giving back io.EOF once they are exhausted.
+type Source interface {
+	Next() (messaging.Delivery, error)
+}
+
+// Decoder gives the message carried by the payload of the type specified, or nil for messages which are to be skipped.
+type Decoder func(messageType string, payload []byte) (interface{}, error)
+
+// JSONDecoder decodes JSON payloads into new values of the same type as the prototype registered
+// for the message type. Messages of types which aren't registered are skipped.
+func JSONDecoder(prototypes map[string]interface{}) Decoder {
+	return func(messageType string, payload []byte) (interface{}, error) {
+		prototype, found := prototypes[messageType]
+		if !found {
+			return nil, nil
+		}
+
+		pointer := reflect.New(reflect.TypeOf(prototype))
+		if err := json.Unmarshal(payload, pointer.Interface()); err != nil {
+			return nil, err
+		}
+
+		return pointer.Elem().Interface(), nil
+	}
+}
+
+// JSONLinesSource reads deliveries written one JSON object per line, for example:
+//
+//	{"message_id": 1, "message_type": "order-placed", "timestamp": "2020-06-30T12:00:00Z", "payload": {"id": 42}}
+type JSONLinesSource struct {
+	decoder *json.Decoder
+	decode  Decoder
+	count   int
+}
+
+func NewJSONLinesSource(reader io.Reader, decode Decoder) *JSONLinesSource {
+	return &JSONLinesSource{decoder: json.NewDecoder(reader), decode: decode}
+}
+
+func (this *JSONLinesSource) Next() (messaging.Delivery, error) {
+	var record struct {
+		SourceID    uint64          `json:"source_id"`
+		MessageID   uint64          `json:"message_id"`
+		MessageType string          `json:"message_type"`
+		ContentType string          `json:"content_type"`
+		Timestamp   time.Time       `json:"timestamp"`
+		Payload     json.RawMessage `json:"payload"`
+	}
+
+	if err := this.decoder.Decode(&record); err == io.EOF {
+		return messaging.Delivery{}, err
+	} else if err != nil {
+		return messaging.Delivery{}, fmt.Errorf("malformed delivery following delivery %d: %s", this.count, err)
+	}
+
+	this.count++
+	message, err := this.decode(record.MessageType, record.Payload)
+	if err != nil {
+		return messaging.Delivery{}, fmt.Errorf("unable to decode delivery %d of type '%s': %s", this.count, record.MessageType, err)
+	}
+
+	return messaging.Delivery{
+		SourceID:    record.SourceID,
+		MessageID:   record.MessageID,
+		MessageType: record.MessageType,
+		ContentType: record.ContentType,
+		Timestamp:   record.Timestamp,
+		Payload:     record.Payload,
+		Message:     message,
+	}, nil
+}
@@ -0,0 +1,79 @@
+package replay
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/messaging/v2"
+)
+
+func TestJSONLinesSourceFixture(t *testing.T) {
+	gunit.Run(new(JSONLinesSourceFixture), t)
+}
+
+type JSONLinesSourceFixture struct {
+	*gunit.Fixture
+
+	decode Decoder
+}
+
+func (this *JSONLinesSourceFixture) Setup() {
+	this.decode = JSONDecoder(map[string]interface{}{"order-placed": OrderPlaced{}, "order-shipped": &OrderShipped{}})
+}
+
+func (this *JSONLinesSourceFixture) TestDeliveriesDecoded() {
+	source := NewJSONLinesSource(strings.NewReader(`
+{"source_id": 7, "message_id": 1, "message_type": "order-placed", "timestamp": "2020-06-30T12:00:00Z", "payload": {"ID": 42}}
+
+{"message_id": 2, "message_type": "order-shipped", "payload": {"ID": 42}}
+{"message_id": 3, "message_type": "unknown", "payload": {}}
+`), this.decode)
+
+	first, err := source.Next()
+	this.So(err, should.BeNil)
+	this.So(first, should.Resemble, messaging.Delivery{
+		SourceID:    7,
+		MessageID:   1,
+		MessageType: "order-placed",
+		Timestamp:   time.Date(2020, 6, 30, 12, 0, 0, 0, time.UTC),
+		Payload:     json.RawMessage(`{"ID": 42}`),
+		Message:     OrderPlaced{ID: 42},
+	})
+
+	second, _ := source.Next()
+	this.So(second.Message, should.Resemble, &OrderShipped{ID: 42})
+
+	third, err := source.Next()
+	this.So(err, should.BeNil)
+	this.So(third.Message, should.BeNil)
+
+	_, err = source.Next()
+	this.So(err, should.Equal, io.EOF)
+}
+
+func (this *JSONLinesSourceFixture) TestMalformedLineReported() {
+	source := NewJSONLinesSource(strings.NewReader(`{"message_id": 1}
+{"message_id": `), this.decode)
+
+	_, _ = source.Next()
+	_, err := source.Next()
+
+	this.So(err, should.NotBeNil)
+	this.So(err, should.NotEqual, io.EOF)
+}
+
+func (this *JSONLinesSourceFixture) TestMalformedPayloadReported() {
+	source := NewJSONLinesSource(strings.NewReader(`{"message_type": "order-placed", "payload": "42"}`), this.decode)
+
+	_, err := source.Next()
+
+	this.So(err.Error(), should.ContainSubstring, "order-placed")
+}
+
+type OrderPlaced struct{ ID int }
+type OrderShipped struct{ ID int }
@@ -0,0 +1,149 @@
+package retention
+
+import (
+	"log"
+	"path"
+	"time"
+
+	"github.com/smartystreets/projector/persist"
+)
+
+// Storage enumerates, copies, and deletes the projected documents.
+type Storage interface {
+	persist.ReadWriter
+	persist.Lister
+	persist.Deleter
+}
+
+// Report tallies the documents examined and acted upon during a single run.
+type Report struct {
+	Examined int
+	Retained int
+	Archived int
+	Deleted  int
+	Failed   int
+}
+
+type Manager struct {
+	storage  Storage
+	rules    []Rule
+	now      func() time.Time
+	dryRun   bool
+	interval time.Duration
+	shutdown chan struct{}
+}
+
+func NewManager(now func() time.Time, storage Storage, rules ...Rule) *Manager {
+	return &Manager{storage: storage, rules: rules, now: now, interval: time.Hour * 24, shutdown: make(chan struct{})}
+}
+
+// WithDryRun logs what would be archived or deleted without changing any documents.
+func (this *Manager) WithDryRun(dryRun bool) *Manager {
+	this.dryRun = dryRun
+	return this
+}
+
+// WithInterval sets the time between runs when the manager is run in the background by Listen.
+func (this *Manager) WithInterval(interval time.Duration) *Manager {
+	this.interval = interval
+	return this
+}
+
+// Listen runs the manager immediately and then periodically until it is closed.
+func (this *Manager) Listen() {
+	for {
+		if report, err := this.Run(); err != nil {
+			log.Printf("[WARN] Unable to apply retention rules: %s\n", err)
+		} else {
+			log.Printf("[INFO] Retention rules applied: %+v\n", report)
+		}
+
+		select {
+		case <-this.shutdown:
+			return
+		case <-time.After(this.interval):
+		}
+	}
+}
+func (this *Manager) Close() {
+	close(this.shutdown)
+}
+
+// Run applies the rules once. Documents which can't be archived or deleted are logged and counted
+// as failures without stopping the run; only invalid rules or a failure to enumerate are returned.
+func (this *Manager) Run() (report Report, err error) {
+	for _, rule := range this.rules {
+		if err = rule.validate(); err != nil {
+			return report, err
+		}
+	}
+
+	matched := make([][]persist.DocumentInfo, len(this.rules))
+	err = this.storage.List("/", func(document persist.DocumentInfo) error {
+		report.Examined++
+		for i, rule := range this.rules {
+			if rule.matches(document.Path) {
+				matched[i] = append(matched[i], document)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	now := this.now()
+	for i, rule := range this.rules {
+		expired := rule.expired(matched[i], now)
+		report.Retained += len(matched[i]) - len(expired)
+		for _, document := range expired {
+			this.expire(rule, document, &report)
+		}
+	}
+
+	return report, nil
+}
+
+func (this *Manager) expire(rule Rule, info persist.DocumentInfo, report *Report) {
+	archive := len(rule.ArchivePrefix) > 0
+	archivePath := path.Join("/", rule.ArchivePrefix, info.Path)
+
+	if this.dryRun {
+		if archive {
+			log.Printf("[INFO] Dry run: would archive expired document [%s] to [%s]\n", info.Path, archivePath)
+			report.Archived++
+		} else {
+			log.Printf("[INFO] Dry run: would delete expired document [%s]\n", info.Path)
+		}
+		report.Deleted++
+		return
+	}
+
+	document := persist.NewListedDocument(info)
+
+	if archive {
+		if err := this.storage.Read(document); err != nil {
+			log.Printf("[WARN] Unable to read expired document [%s] for archiving: %s\n", info.Path, err)
+			report.Failed++
+			return
+		} else if err = this.storage.Write(document.Relocate(archivePath)); err != nil {
+			log.Printf("[WARN] Unable to archive expired document [%s] to [%s]: %s\n", info.Path, archivePath, err)
+			report.Failed++
+			return
+		}
+		report.Archived++
+	}
+
+	// The deletion is conditional upon the version listed (or archived), so a document
+	// which has been written since it was found to have expired is left alone.
+	if err := this.storage.Delete(document); err == persist.ErrConcurrentWrite {
+		log.Printf("[INFO] Expired document [%s] has since been written and will be retained.\n", info.Path)
+		report.Retained++
+	} else if err != nil {
+		log.Printf("[WARN] Unable to delete expired document [%s]: %s\n", info.Path, err)
+		report.Failed++
+	} else {
+		report.Deleted++
+	}
+}
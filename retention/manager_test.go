@@ -0,0 +1,152 @@
+package retention
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector/persist"
+	"github.com/smartystreets/projector/persist/persisttest"
+)
+
+func TestManagerFixture(t *testing.T) {
+	gunit.Run(new(ManagerFixture), t)
+}
+
+type ManagerFixture struct {
+	*gunit.Fixture
+
+	now     time.Time
+	storage *persisttest.Storage
+}
+
+func (this *ManagerFixture) Setup() {
+	this.now = time.Date(2020, 6, 30, 0, 0, 0, 0, time.UTC)
+	this.storage = persisttest.NewStorage("fake")
+	this.add("/daily-2020-06-01.json", this.now.Add(-time.Hour*24*29))
+	this.add("/daily-2020-06-02.json", this.now.Add(-time.Hour*24*28))
+	this.add("/daily-2020-06-03.json", this.now.Add(-time.Hour*24*27))
+	this.add("/daily-2020-06-29.json", this.now.Add(-time.Hour))
+	this.add("/totals.json", this.now.Add(-time.Hour*24*365))
+}
+func (this *ManagerFixture) add(path string, modified time.Time) {
+	this.storage.Put(path, `{"path":"`+path+`"}`)
+	this.storage.Modified[path] = modified
+}
+func (this *ManagerFixture) clock() time.Time { return this.now }
+
+func (this *ManagerFixture) TestDocumentsOlderThanKeepForDeleted() {
+	manager := NewManager(this.clock, this.storage, Rule{Pattern: "/daily-*.json", KeepFor: time.Hour * 24 * 28})
+
+	report, err := manager.Run()
+
+	this.So(err, should.BeNil)
+	this.So(report, should.Resemble, Report{Examined: 5, Retained: 2, Deleted: 2})
+	this.So(this.storage.Deleted, should.Resemble, []string{"/daily-2020-06-02.json", "/daily-2020-06-01.json"})
+	this.So(this.storage.DeletedVersions, should.Resemble, []interface{}{
+		this.storage.Version("/daily-2020-06-02.json"),
+		this.storage.Version("/daily-2020-06-01.json"),
+	})
+}
+
+func (this *ManagerFixture) TestLastPeriodsKept() {
+	manager := NewManager(this.clock, this.storage, Rule{Pattern: "/daily-*.json", KeepLast: 3})
+
+	report, _ := manager.Run()
+
+	this.So(report.Deleted, should.Equal, 1)
+	this.So(this.storage.Deleted, should.Resemble, []string{"/daily-2020-06-01.json"})
+}
+
+func (this *ManagerFixture) TestDocumentRetainedWhenAnyCriterionSatisfied() {
+	manager := NewManager(this.clock, this.storage, Rule{Pattern: "/daily-*.json", KeepLast: 1, KeepFor: time.Hour * 24 * 28})
+
+	_, _ = manager.Run()
+
+	this.So(this.storage.Deleted, should.Resemble, []string{"/daily-2020-06-02.json", "/daily-2020-06-01.json"})
+}
+
+func (this *ManagerFixture) TestFirstMatchingRuleApplies() {
+	manager := NewManager(this.clock, this.storage,
+		Rule{Pattern: "/daily-2020-06-0*.json", KeepLast: 10},
+		Rule{Pattern: "/*.json", KeepFor: time.Hour * 2})
+
+	_, _ = manager.Run()
+
+	this.So(this.storage.Deleted, should.Resemble, []string{"/totals.json"})
+}
+
+func (this *ManagerFixture) TestExpiredDocumentsArchivedBeforeDeletion() {
+	manager := NewManager(this.clock, this.storage, Rule{Pattern: "/daily-*.json", KeepLast: 3, ArchivePrefix: "archive"})
+
+	report, _ := manager.Run()
+
+	this.So(report, should.Resemble, Report{Examined: 5, Retained: 3, Archived: 1, Deleted: 1})
+	this.So(this.storage.Bodies["/archive/daily-2020-06-01.json"], should.Equal, `{"path":"/daily-2020-06-01.json"}`)
+	this.So(this.storage.Deleted, should.Resemble, []string{"/daily-2020-06-01.json"})
+}
+
+func (this *ManagerFixture) TestFailedArchiveNotDeleted() {
+	this.storage.WriteError = errors.New("BOINK!")
+	manager := NewManager(this.clock, this.storage, Rule{Pattern: "/daily-*.json", KeepLast: 3, ArchivePrefix: "/archive"})
+
+	report, err := manager.Run()
+
+	this.So(err, should.BeNil)
+	this.So(report.Failed, should.Equal, 1)
+	this.So(this.storage.Deleted, should.BeEmpty)
+}
+
+func (this *ManagerFixture) TestDocumentWrittenSinceListingRetained() {
+	this.storage.DeleteError = persist.ErrConcurrentWrite
+	manager := NewManager(this.clock, this.storage, Rule{Pattern: "/totals.json", KeepFor: time.Hour})
+
+	report, _ := manager.Run()
+
+	this.So(report, should.Resemble, Report{Examined: 5, Retained: 1})
+}
+
+func (this *ManagerFixture) TestDryRunChangesNothing() {
+	manager := NewManager(this.clock, this.storage, Rule{Pattern: "/daily-*.json", KeepLast: 3, ArchivePrefix: "/archive"}).WithDryRun(true)
+
+	report, _ := manager.Run()
+
+	this.So(report, should.Resemble, Report{Examined: 5, Retained: 3, Archived: 1, Deleted: 1})
+	this.So(this.storage.Deleted, should.BeEmpty)
+	this.So(this.storage.Bodies, should.NotContainKey, "/archive/daily-2020-06-01.json")
+}
+
+func (this *ManagerFixture) TestInvalidRulesRejected() {
+	for _, rule := range []Rule{
+		{Pattern: "", KeepLast: 1},
+		{Pattern: "/[", KeepLast: 1},
+		{Pattern: "/*.json"},
+		{Pattern: "/*.json", KeepLast: -1, KeepFor: time.Hour},
+	} {
+		_, err := NewManager(this.clock, this.storage, rule).Run()
+		this.So(err, should.NotBeNil)
+	}
+	this.So(this.storage.Lists, should.Equal, 0)
+}
+
+func (this *ManagerFixture) TestListingFailureReturned() {
+	this.storage.ListError = errors.New("BOINK!")
+
+	_, err := NewManager(this.clock, this.storage, Rule{Pattern: "/*", KeepLast: 1}).Run()
+
+	this.So(err, should.Equal, this.storage.ListError)
+}
+
+func (this *ManagerFixture) TestListenRunsUntilClosed() {
+	manager := NewManager(this.clock, this.storage, Rule{Pattern: "/*", KeepLast: 100}).WithInterval(time.Millisecond)
+	done := make(chan struct{})
+	go func() { manager.Listen(); close(done) }()
+
+	time.Sleep(time.Millisecond * 10)
+	manager.Close()
+	<-done
+
+	this.So(this.storage.Lists, should.BeGreaterThan, 1)
+}
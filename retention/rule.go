@@ -0,0 +1,102 @@
+package retention
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/smartystreets/projector/persist"
+)
+
+// Rule decides which of the documents matching its pattern have expired. A document is retained
+// so long as it satisfies any of the criteria given (KeepFor, KeepLast); otherwise it has expired.
+type Rule struct {
+	// Pattern selects documents by their paths, using the syntax of path.Match (e.g. "/totals/daily-*.json").
+	// Where several rules match the same document, the first of them applies.
+	Pattern string
+
+	// KeepFor retains the documents which have been modified within the duration.
+	KeepFor time.Duration
+
+	// KeepLast retains the documents with the greatest paths, which are the most
+	// recent periods when the paths embed sortable dates (e.g. "daily-2020-06-01.json").
+	KeepLast int
+
+	// ArchivePrefix, when given, receives a copy of each expired document at the
+	// same path beneath the prefix before the document itself is deleted.
+	ArchivePrefix string
+}
+
+func (this Rule) validate() error {
+	if _, err := path.Match(this.Pattern, "/"); err != nil || len(this.Pattern) == 0 {
+		return fmt.Errorf("retention rule has a malformed pattern: '%s'", this.Pattern)
+	} else if this.KeepFor <= 0 && this.KeepLast <= 0 {
+		return fmt.Errorf("retention rule for '%s' must keep documents for a duration or keep the last few of them", this.Pattern)
+	} else if this.KeepFor < 0 || this.KeepLast < 0 {
+		return fmt.Errorf("retention rule for '%s' cannot keep a negative duration or number of documents", this.Pattern)
+	}
+	return nil
+}
+func (this Rule) matches(documentPath string) bool {
+	matched, _ := path.Match(this.Pattern, documentPath)
+	return matched
+}
+func (this Rule) expired(documents []persist.DocumentInfo, now time.Time) (expired []persist.DocumentInfo) {
+	sort.Slice(documents, func(i, j int) bool { return documents[i].Path > documents[j].Path })
+
+	for i, document := range documents {
+		if this.KeepLast > 0 && i < this.KeepLast {
+			continue
+		} else if this.KeepFor > 0 && document.LastModified.After(now.Add(-this.KeepFor)) {
+			continue
+		}
+
+		expired = append(expired, document)
+	}
+
+	return expired
+}
+
+// LoadRules reads the rules from a JSON file in which durations are written as strings, for example:
+//
+//	[{"pattern": "/totals/daily-*.json", "keep_for": "720h", "keep_last": 7, "archive_prefix": "/archive"}]
+func LoadRules(filename string) ([]Rule, error) {
+	raw, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var contents []struct {
+		Pattern       string `json:"pattern"`
+		KeepFor       string `json:"keep_for"`
+		KeepLast      int    `json:"keep_last"`
+		ArchivePrefix string `json:"archive_prefix"`
+	}
+	if err = json.Unmarshal(raw, &contents); err != nil {
+		return nil, fmt.Errorf("malformed retention rules file '%s': %s", filename, err)
+	}
+
+	var rules []Rule
+	for _, item := range contents {
+		rule := Rule{Pattern: item.Pattern, KeepLast: item.KeepLast, ArchivePrefix: item.ArchivePrefix}
+		if len(item.KeepFor) > 0 {
+			if rule.KeepFor, err = time.ParseDuration(item.KeepFor); err != nil {
+				return nil, fmt.Errorf("retention rule for '%s' has a malformed duration: %s", item.Pattern, err)
+			}
+		}
+		if err = rule.validate(); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	if len(rules) == 0 {
+		return nil, errors.New("no retention rules were found in " + filename)
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,116 @@
+package transform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+
+	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist"
+)
+
+// ConflictResolver settles a conflict between a document as modified locally and as since written by another process.
+type ConflictResolver interface {
+	// Resolve prepares the local document to be written again (true) or has it read afresh and reapplied (false).
+	Resolve(local projector.Document, storage persist.Reader) (retry bool, err error)
+}
+
+// Reapply reads the document afresh and applies the messages to it again, which suits any document but
+// repeats the work of the whole batch. It is the default.
+func Reapply() ConflictResolver { return reapply{} }
+
+type reapply struct{}
+
+func (reapply) Resolve(projector.Document, persist.Reader) (bool, error) { return false, nil }
+
+// LastWriterWins writes the local document again in place of that written by the other process,
+// discarding its changes, which suits documents which are wholly replaced by each message.
+func LastWriterWins() ConflictResolver { return lastWriterWins{} }
+
+type lastWriterWins struct{}
+
+func (lastWriterWins) Resolve(local projector.Document, storage persist.Reader) (bool, error) {
+	remote := persist.RawDocumentOf(local)
+	if err := storage.Read(remote); err != nil {
+		log.Printf("[WARN] Unable to read the version of document [%s] to replace: %s", local.Path(), err)
+		return false, nil
+	}
+
+	local.SetVersion(stored(remote)) // still conditional, should yet another process write in the meantime
+	return true, nil
+}
+
+// MergeWith reads the document written by the other process into a new instance, which the merge function merges
+// into the local document (e.g. as with CRDTs). Documents must be pointers to structs.
+func MergeWith(merge func(local, remote projector.Document)) ConflictResolver {
+	return mergeWith{merge: merge}
+}
+
+type mergeWith struct {
+	merge func(local, remote projector.Document)
+}
+
+func (this mergeWith) Resolve(local projector.Document, storage persist.Reader) (bool, error) {
+	remote, err := blank(local)
+	if err != nil {
+		return false, err
+	}
+
+	raw := persist.RawDocumentOf(local) // stored at the key of the local document, which the blank one may not share
+	if err := storage.Read(raw); err != nil {
+		log.Printf("[WARN] Unable to read document [%s] to merge: %s", local.Path(), err)
+		return false, nil
+	} else if len(raw.Body()) > 0 {
+		if err := json.Unmarshal(raw.Body(), remote); err != nil {
+			return false, err
+		}
+	}
+
+	this.merge(local, remote)
+	local.SetVersion(stored(raw))
+	return true, nil
+}
+
+// Merge merges the changes of a projector.Mergeable document into the document written by the other process, and
+// is the default for such documents. Other documents are read afresh and have the messages applied to them again.
+func Merge() ConflictResolver { return mergeable{} }
+
+type mergeable struct{}
+
+func (mergeable) Resolve(local projector.Document, storage persist.Reader) (bool, error) {
+	if _, ok := local.(projector.Mergeable); !ok {
+		return false, nil
+	}
+
+	return mergeWith{merge: mergeDocuments}.Resolve(local, storage)
+}
+func mergeDocuments(local, remote projector.Document) { local.(projector.Mergeable).Merge(remote) }
+
+// blank gives a new, reset instance of the type of the document, which must be a pointer to a struct.
+func blank(document projector.Document) (projector.Document, error) {
+	kind := reflect.TypeOf(document)
+	if kind.Kind() != reflect.Ptr || kind.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %s", ErrUnmergeable, kind)
+	}
+
+	value := reflect.New(kind.Elem()).Interface().(projector.Document)
+	value.Reset()
+	return value, nil
+}
+
+// FailOnConflict gives up saving the document upon any conflict, which suits documents expected to have but one writer.
+func FailOnConflict() ConflictResolver { return failOnConflict{} }
+
+type failOnConflict struct{}
+
+func (failOnConflict) Resolve(projector.Document, persist.Reader) (bool, error) {
+	return false, ErrUnresolvedConflict
+}
+
+var (
+	ErrUnresolvedConflict = errors.New("the document was written by another process and the conflict can't be resolved")
+	ErrTooManyConflicts   = errors.New("the document couldn't be written within the limit of attempts")
+	ErrUnmergeable        = errors.New("only documents which are pointers to structs can be merged")
+)
@@ -0,0 +1,239 @@
+package transform
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/projector"
+	"github.com/smartystreets/projector/persist"
+)
+
+func TestConflictFixture(t *testing.T) {
+	gunit.Run(new(ConflictFixture), t)
+}
+
+type ConflictFixture struct {
+	*gunit.Fixture
+
+	store    *FakeStorage
+	document *FakeDocument
+}
+
+func (this *ConflictFixture) Setup() {
+	this.store = NewFakeStorage()
+	this.store.writeErrorCount = 1
+	this.store.onRead = func(document projector.Document) { document.SetVersion("remote") }
+	this.document = &FakeDocument{}
+}
+
+func (this *ConflictFixture) transform(options ...Option) error {
+	config := newConfiguration(append(options, Documents(this.document)))
+	return newSimpleTransformer(this.document, this.store, config).Transform(time.Now(), deliver("a", "b"))
+}
+
+func (this *ConflictFixture) TestReappliedByDefault() {
+	this.transform()
+
+	this.So(this.document.reset, should.Equal, 1)
+	this.So(this.document.apply, should.Equal, 4)
+	this.So(this.store.writeCount, should.Equal, 2)
+}
+
+func (this *ConflictFixture) TestLastWriterWinsWritesAgainUponRemoteVersion() {
+	this.transform(ResolveConflicts(LastWriterWins()))
+
+	this.So(this.document.reset, should.Equal, 0)
+	this.So(this.document.apply, should.Equal, 2)
+	this.So(this.document.version, should.Equal, "remote")
+	this.So(this.store.writeCount, should.Equal, 2)
+}
+
+func (this *ConflictFixture) TestMergedWithRemoteDocument() {
+	var merged []projector.Document
+	merge := func(local, remote projector.Document) { merged = append(merged, local, remote) }
+
+	this.transform(ResolveConflicts(MergeWith(merge)))
+
+	this.So(merged, should.HaveLength, 2)
+	this.So(merged[0], should.Equal, this.document)
+	this.So(merged[1], should.NotEqual, this.document)
+	this.So(merged[1].(*FakeDocument).reset, should.Equal, 1) // a new instance, read afresh
+	this.So(this.document.apply, should.Equal, 2)
+	this.So(this.document.version, should.Equal, "remote")
+	this.So(this.store.writeCount, should.Equal, 2)
+}
+
+func (this *ConflictFixture) TestDocumentOtherThanPointerToStructNotMerged() {
+	document := ValueDocument{}
+	merge := func(local, remote projector.Document) { panic("merged") }
+	transformer := newSimpleTransformer(document, this.store, newConfiguration([]Option{
+		Documents(document), ResolveConflicts(MergeWith(merge)),
+	}))
+
+	err := transformer.Transform(time.Now(), deliver("a"))
+
+	this.So(errors.Is(err, ErrUnmergeable), should.BeTrue)
+	this.So(this.store.writeCount, should.Equal, 1)
+}
+
+func (this *ConflictFixture) TestDatedDocumentMergedWithThatAtItsKey() {
+	document := &DatedDocument{day: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)}
+	this.storeAtKeyOf(document)
+	merge := func(local, remote projector.Document) { local.(*DatedDocument).Count += remote.(*DatedDocument).Count }
+	transformer := newSimpleTransformer(document, this.store, newConfiguration([]Option{
+		Documents(document), ResolveConflicts(MergeWith(merge)),
+	}))
+
+	err := transformer.Transform(time.Now(), deliver("a", "b"))
+
+	this.So(err, should.BeNil)
+	this.So(document.Count, should.Equal, 12)
+	this.So(document.version, should.Equal, "remote")
+	this.So(this.store.writeCount, should.Equal, 2)
+}
+
+// storeAtKeyOf has only reads at the key of the document, by a template including its type and date, find it.
+func (this *ConflictFixture) storeAtKeyOf(document projector.Document) {
+	mapper, _ := persist.NewTemplateKeyMapper("{type}/{date}/{path}", "")
+	key := mapper.Key(document)
+	this.store.onRead = func(read projector.Document) {
+		if mapper.Key(read) == key {
+			_ = json.Unmarshal([]byte(`{"Count":10}`), read)
+			read.SetVersion("remote")
+		}
+	}
+}
+
+func (this *ConflictFixture) TestMergeableDocumentMergedByDefault() {
+	document := &MergeableDocument{}
+	this.store.onRead = func(read projector.Document) {
+		_ = json.Unmarshal([]byte(`{"Count":10}`), read)
+		read.SetVersion("remote")
+	}
+	transformer := newSimpleTransformer(document, this.store, newConfiguration([]Option{Documents(document)}))
+
+	err := transformer.Transform(time.Now(), deliver("a", "b"))
+
+	this.So(err, should.BeNil)
+	this.So(document.applied, should.Equal, 2) // not applied again
+	this.So(document.Count, should.Equal, 12)
+	this.So(document.pending, should.Equal, 0)
+	this.So(document.version, should.Equal, "remote")
+	this.So(this.store.writeCount, should.Equal, 2)
+}
+
+func (this *ConflictFixture) TestDatedMergeableDocumentMergedByDefaultWithThatAtItsKey() {
+	document := &DatedDocument{day: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)}
+	this.storeAtKeyOf(document)
+	transformer := newSimpleTransformer(document, this.store, newConfiguration([]Option{Documents(document)}))
+
+	err := transformer.Transform(time.Now(), deliver("a", "b"))
+
+	this.So(err, should.BeNil)
+	this.So(document.Count, should.Equal, 12)
+	this.So(document.version, should.Equal, "remote")
+	this.So(this.store.writeCount, should.Equal, 2)
+}
+
+func (this *ConflictFixture) TestOnlyChangesSinceSavedMerged() {
+	document := &MergeableDocument{}
+	this.store.onRead = func(read projector.Document) { _ = json.Unmarshal([]byte(`{"Count":10}`), read) }
+	transformer := newSimpleTransformer(document, this.store, newConfiguration([]Option{Documents(document)}))
+	this.store.writeErrorCount = 0
+	_ = transformer.Transform(time.Now(), deliver("a", "b"))
+
+	this.store.writeCount, this.store.writeErrorCount = 0, 1
+	_ = transformer.Transform(time.Now(), deliver("c"))
+
+	this.So(document.Count, should.Equal, 11)
+}
+
+func (this *ConflictFixture) TestUnmergeableDocumentReappliedByMerge() {
+	this.transform(ResolveConflicts(Merge()))
+
+	this.So(this.document.reset, should.Equal, 1)
+	this.So(this.document.apply, should.Equal, 4)
+}
+
+func (this *ConflictFixture) TestFailureGivesUp() {
+	err := this.transform(ResolveConflicts(FailOnConflict()))
+
+	this.So(errors.Is(err, ErrUnresolvedConflict), should.BeTrue)
+	this.So(err.Error(), should.Equal, "unable to save document [/0]: "+ErrUnresolvedConflict.Error())
+	this.So(this.store.writeCount, should.Equal, 1)
+}
+
+func (this *ConflictFixture) TestResolverChosenForEachDocument() {
+	other := &FakeDocument{index: 1}
+	config := newConfiguration([]Option{
+		ResolveConflicts(LastWriterWins()),
+		ResolveConflicts(FailOnConflict(), other),
+	})
+
+	this.So(config.resolver(this.document), should.Resemble, LastWriterWins())
+	this.So(config.resolver(other), should.Resemble, FailOnConflict())
+	this.So(newConfiguration(nil).resolver(other), should.Resemble, Reapply())
+	this.So(newConfiguration(nil).resolver(&MergeableDocument{}), should.Resemble, Merge())
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+// MergeableDocument counts the messages applied, keeping those not yet saved to merge upon a conflict.
+type MergeableDocument struct {
+	Count int
+
+	pending int
+	applied int
+	version interface{}
+}
+
+func (this *MergeableDocument) Apply(message interface{}) bool {
+	this.Count++
+	this.pending++
+	this.applied++
+	return true
+}
+func (this *MergeableDocument) Merge(remote projector.Document) {
+	this.Count = remote.(*MergeableDocument).Count + this.pending
+}
+func (this *MergeableDocument) Saved()                                        { this.pending = 0 }
+func (this *MergeableDocument) Lapse(now time.Time) (next projector.Document) { return this }
+func (this *MergeableDocument) Path() string                                  { return "/mergeable" }
+func (this *MergeableDocument) Reset()                                        { this.Count, this.pending = 0, 0; this.version = nil }
+func (this *MergeableDocument) SetVersion(value interface{})                  { this.version = value }
+func (this *MergeableDocument) Version() interface{}                          { return this.version }
+
+// DatedDocument counts the messages applied to it over a day, by which its key may be dated.
+type DatedDocument struct {
+	Count int
+
+	pending int
+	day     time.Time
+	version interface{}
+}
+
+func (this *DatedDocument) Apply(message interface{}) bool { this.Count++; this.pending++; return true }
+func (this *DatedDocument) Merge(remote projector.Document) {
+	this.Count = remote.(*DatedDocument).Count + this.pending
+}
+func (this *DatedDocument) Saved()                                 { this.pending = 0 }
+func (this *DatedDocument) Date() time.Time                        { return this.day }
+func (this *DatedDocument) Lapse(now time.Time) projector.Document { return this }
+func (this *DatedDocument) Path() string                           { return "/dated" }
+func (this *DatedDocument) Reset()                                 { this.Count, this.pending = 0, 0; this.version = nil }
+func (this *DatedDocument) SetVersion(value interface{})           { this.version = value }
+func (this *DatedDocument) Version() interface{}                   { return this.version }
+
+// ValueDocument isn't a pointer, and so can't be merged.
+type ValueDocument struct{}
+
+func (ValueDocument) Lapse(time.Time) projector.Document { return ValueDocument{} }
+func (ValueDocument) Apply(interface{}) bool             { return true }
+func (ValueDocument) Path() string                       { return "/value" }
+func (ValueDocument) Reset()                             {}
+func (ValueDocument) SetVersion(interface{})             {}
+func (ValueDocument) Version() interface{}               { return nil }
@@ -0,0 +1,46 @@
+package transform
+
+import "github.com/smartystreets/projector"
+
+// Identifiable messages have an identity which is the same across redeliveries.
+type Identifiable interface {
+	Identity() string
+}
+
+func identifiable(message interface{}) string {
+	if identifiable, ok := message.(Identifiable); ok {
+		return identifiable.Identity()
+	}
+	return ""
+}
+
+// DefaultDeduplicationCapacity is the number of identities each document remembers unless specified otherwise.
+const DefaultDeduplicationCapacity = 1000
+
+type deduplicator struct {
+	identify func(interface{}) string
+	capacity int
+}
+
+// duplicate reports whether the message was already applied to the document, otherwise remembering
+// that it now has been. Messages without an identity and documents which don't remember are never duplicates.
+func (this deduplicator) duplicate(document projector.Document, message interface{}) bool {
+	if this.identify == nil {
+		return false
+	}
+
+	deduplicated, ok := document.(projector.Deduplicated)
+	if !ok {
+		return false
+	}
+
+	id := this.identify(message)
+	if len(id) == 0 {
+		return false
+	} else if deduplicated.Seen(id) {
+		return true
+	}
+
+	deduplicated.MarkSeen(id, this.capacity)
+	return false
+}
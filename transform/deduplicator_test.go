@@ -0,0 +1,116 @@
+package transform
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/messaging/v2"
+	"github.com/smartystreets/projector"
+)
+
+func TestDeduplicatorFixture(t *testing.T) {
+	gunit.Run(new(DeduplicatorFixture), t)
+}
+
+type DeduplicatorFixture struct {
+	*gunit.Fixture
+
+	store    *FakeStorage
+	document *DeduplicatedDocument
+}
+
+func (this *DeduplicatorFixture) Setup() {
+	this.store = NewFakeStorage()
+	this.document = &DeduplicatedDocument{}
+}
+
+func (this *DeduplicatorFixture) transform(option Option, messages ...interface{}) {
+	config := newConfiguration([]Option{option, Documents(this.document)})
+	var deliveries []messaging.Delivery
+	for _, message := range messages {
+		deliveries = append(deliveries, messaging.Delivery{Message: message})
+	}
+	newTransformer(this.store, config).Transform(time.Now(), deliveries)
+}
+
+func (this *DeduplicatorFixture) TestDuplicateMessagesSkipped() {
+	this.transform(Deduplicate(nil, 10), IdentifiedMessage("a"), IdentifiedMessage("b"), IdentifiedMessage("a"))
+	this.transform(Deduplicate(nil, 10), IdentifiedMessage("b"), IdentifiedMessage("c"))
+
+	this.So(this.document.messages, should.Resemble, []interface{}{
+		IdentifiedMessage("a"), IdentifiedMessage("b"), IdentifiedMessage("c"),
+	})
+	this.So(this.document.SeenMessages, should.Resemble, []string{"a", "b", "c"})
+}
+
+func (this *DeduplicatorFixture) TestIdentitiesForgottenBeyondCapacity() {
+	this.transform(Deduplicate(nil, 2), IdentifiedMessage("a"), IdentifiedMessage("b"), IdentifiedMessage("c"), IdentifiedMessage("a"))
+
+	this.So(this.document.messages, should.HaveLength, 4)
+	this.So(this.document.SeenMessages, should.Resemble, []string{"c", "a"})
+}
+
+func (this *DeduplicatorFixture) TestCapacityDefaultedWhenUnspecified() {
+	config := newConfiguration([]Option{Deduplicate(nil, 0)})
+
+	this.So(config.deduplicator.capacity, should.Equal, DefaultDeduplicationCapacity)
+}
+
+func (this *DeduplicatorFixture) TestIdentitiesReadAfreshAfterConflict() {
+	this.store.writeErrorCount = 1
+	this.store.onRead = func(read projector.Document) { _ = json.Unmarshal([]byte(`{}`), read) } // nothing seen yet
+
+	this.transform(Deduplicate(nil, 10), IdentifiedMessage("a"), IdentifiedMessage("b"))
+
+	this.So(this.document.messages, should.Resemble, []interface{}{
+		IdentifiedMessage("a"), IdentifiedMessage("b"), IdentifiedMessage("a"), IdentifiedMessage("b"),
+	})
+	this.So(this.document.SeenMessages, should.Resemble, []string{"a", "b"})
+	this.So(this.store.writeCount, should.Equal, 2)
+}
+
+func (this *DeduplicatorFixture) TestIdentityExtractedByFunction() {
+	identify := func(message interface{}) string { return message.(string)[0:1] }
+
+	this.transform(Deduplicate(identify, 10), "a1", "b1", "a2")
+
+	this.So(this.document.messages, should.Resemble, []interface{}{"a1", "b1"})
+}
+
+func (this *DeduplicatorFixture) TestUnidentifiedMessagesAlwaysApplied() {
+	this.transform(Deduplicate(nil, 10), "a", "a")
+
+	this.So(this.document.messages, should.Resemble, []interface{}{"a", "a"})
+	this.So(this.document.SeenMessages, should.BeEmpty)
+}
+
+func (this *DeduplicatorFixture) TestWithoutDeduplicationDuplicatesApplied() {
+	this.transform(Sleep(0), IdentifiedMessage("a"), IdentifiedMessage("a"))
+
+	this.So(this.document.messages, should.HaveLength, 2)
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+type IdentifiedMessage string
+
+func (this IdentifiedMessage) Identity() string { return string(this) }
+
+type DeduplicatedDocument struct {
+	projector.DeduplicationInfo
+
+	messages []interface{}
+}
+
+func (this *DeduplicatedDocument) Apply(message interface{}) bool {
+	this.messages = append(this.messages, message)
+	return true
+}
+func (this *DeduplicatedDocument) Lapse(now time.Time) (next projector.Document) { return this }
+func (this *DeduplicatedDocument) Path() string                                  { return "/deduplicated" }
+func (this *DeduplicatedDocument) Reset()                                        { this.DeduplicationInfo.Reset() }
+func (this *DeduplicatedDocument) SetVersion(value interface{})                  {}
+func (this *DeduplicatedDocument) Version() interface{}                          { return nil }
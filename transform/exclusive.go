@@ -0,0 +1,47 @@
+package transform
+
+import (
+	"log"
+	"time"
+
+	"github.com/smartystreets/messaging/v2"
+)
+
+// Lease is held by no more than one process at a time, such as a *lease.Lease renewed in the background.
+type Lease interface {
+	Held() bool
+}
+
+// disowner is implemented by transformers which keep their documents between batches, which disown has
+// read afresh before they're next written.
+type disowner interface {
+	disown()
+}
+
+// exclusiveTransformer only projects deliveries while the lease is held, reading the documents afresh
+// having stood by, as another process has since been writing them.
+type exclusiveTransformer struct {
+	lease Lease
+	inner Transformer
+	poll  time.Duration
+	sleep func(time.Duration)
+}
+
+func newExclusiveTransformer(lease Lease, inner Transformer, poll time.Duration, sleep func(time.Duration)) Transformer {
+	return &exclusiveTransformer{lease: lease, inner: inner, poll: poll, sleep: sleep}
+}
+
+func (this *exclusiveTransformer) Transform(now time.Time, deliveries []messaging.Delivery) error {
+	if !this.lease.Held() {
+		log.Println("[INFO] Standing by until the lease is held.")
+		for !this.lease.Held() {
+			this.sleep(this.poll)
+		}
+		log.Println("[INFO] The lease is held; projecting deliveries.")
+		if inner, ok := this.inner.(disowner); ok {
+			inner.disown()
+		}
+	}
+
+	return this.inner.Transform(now, deliveries)
+}
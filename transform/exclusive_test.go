@@ -0,0 +1,83 @@
+package transform
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestExclusiveTransformerFixture(t *testing.T) {
+	gunit.Run(new(ExclusiveTransformerFixture), t)
+}
+
+type ExclusiveTransformerFixture struct {
+	*gunit.Fixture
+
+	lease       *FakeLease
+	inner       *FakeTransformer
+	transformer Transformer
+	slept       []time.Duration
+}
+
+func (this *ExclusiveTransformerFixture) Setup() {
+	this.lease = &FakeLease{}
+	this.inner = &FakeTransformer{}
+	this.transformer = newExclusiveTransformer(this.lease, this.inner, time.Second, this.sleep)
+}
+func (this *ExclusiveTransformerFixture) sleep(duration time.Duration) {
+	this.slept = append(this.slept, duration)
+}
+
+func (this *ExclusiveTransformerFixture) TestTransformedImmediatelyWhileLeaseHeld() {
+	this.lease.heldAfter = 0
+
+	this.transformer.Transform(time.Now(), deliver("a"))
+
+	this.So(this.inner.calls, should.Equal, 1)
+	this.So(this.slept, should.BeEmpty)
+}
+
+func (this *ExclusiveTransformerFixture) TestStandsByUntilLeaseHeld() {
+	this.lease.heldAfter = 3
+
+	this.transformer.Transform(time.Now(), deliver("a"))
+
+	this.So(this.inner.calls, should.Equal, 1)
+	this.So(this.slept, should.Resemble, []time.Duration{time.Second, time.Second})
+}
+
+func (this *ExclusiveTransformerFixture) TestDocumentsReadAfreshHavingStoodBy() {
+	store, document := NewFakeStorage(), &FakeDocument{}
+	this.transformer = newExclusiveTransformer(this.lease, newTransformer(store, newConfiguration([]Option{
+		Documents(document),
+	})), time.Second, this.sleep)
+	this.lease.heldAfter = 1
+
+	this.transformer.Transform(time.Now(), deliver("a"))
+	this.transformer.Transform(time.Now(), deliver("b"))
+
+	this.So(store.readCount, should.Equal, 1)
+	this.So(document.reset, should.Equal, 1)
+	this.So(store.writeCount, should.Equal, 2)
+}
+
+func (this *ExclusiveTransformerFixture) TestConfiguredTransformerIsExclusive() {
+	transformer := NewTransformer(NewFakeStorage(), Documents(&FakeDocument{}), Exclusive(this.lease))
+
+	this.So(transformer, should.HaveSameTypeAs, &exclusiveTransformer{})
+}
+
+/* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
+
+// FakeLease is held once it has been asked whether it's held the given number of times.
+type FakeLease struct {
+	asked     int
+	heldAfter int
+}
+
+func (this *FakeLease) Held() bool {
+	this.asked++
+	return this.asked > this.heldAfter
+}
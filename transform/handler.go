@@ -1,6 +1,7 @@
 package transform
 
 import (
+	"log"
 	"time"
 
 	"github.com/smartystreets/listeners"
@@ -13,13 +14,18 @@ type Handler struct {
 	input       <-chan messaging.Delivery
 	output      chan<- interface{}
 	transformer Transformer
-	messages    []interface{}
+	deliveries  []messaging.Delivery
 	now         func() time.Time
 	sleep       time.Duration
 }
 
 func NewHandler(now func() time.Time, i <-chan messaging.Delivery, o chan<- interface{}, rw persist.ReadWriter, d ...projector.Document) listeners.Listener {
-	return newHandler(i, o, newTransformer(rw, d...), now)
+	return New(i, o, rw, Clock(now), Documents(d...))
+}
+
+func New(input <-chan messaging.Delivery, output chan<- interface{}, storage persist.ReadWriter, options ...Option) listeners.Listener {
+	config := newConfiguration(options)
+	return newHandler(input, output, NewTransformer(storage, options...), config.now).WithSleep(config.sleep)
 }
 
 func newHandler(input <-chan messaging.Delivery, output chan<- interface{}, transformer Transformer, now func() time.Time) *Handler {
@@ -31,16 +37,21 @@ func (this *Handler) WithSleep(duration time.Duration) *Handler {
 	return this
 }
 
+// Listen projects the deliveries received in batches, acknowledging each batch once projected. Should a batch fail
+// to be projected within the limits configured, Listen panics rather than acknowledge it, such that the deliveries
+// are received again once the process has been restarted.
 func (this *Handler) Listen() {
 	for delivery := range this.input {
-		this.messages = append(this.messages, delivery.Message)
+		this.deliveries = append(this.deliveries, delivery)
 		if len(this.input) > 0 {
 			continue
 		}
 
-		this.transformer.Transform(this.now(), this.messages)
+		if err := this.transformer.Transform(this.now(), this.deliveries); err != nil {
+			log.Panicf("[ERROR] Unable to project %d deliveries, which remain unacknowledged: %s", len(this.deliveries), err)
+		}
 		this.output <- delivery.Receipt
-		this.messages = this.messages[0:0]
+		this.deliveries = this.deliveries[0:0]
 		time.Sleep(this.sleep)
 	}
 
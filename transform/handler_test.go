@@ -1,6 +1,7 @@
 package transform
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -39,21 +40,46 @@ func (this *HandlerFixture) TestMessagesHandled() {
 
 	this.So(this.transformer.calls, should.Equal, 1)
 	this.So(this.transformer.now, should.Equal, this.now)
-	this.So(this.transformer.messages, should.Resemble, []interface{}{1, 2})
+	this.So(this.transformer.deliveries, should.Resemble, []messaging.Delivery{{Message: 1, Receipt: 11}, {Message: 2, Receipt: 12}})
 	this.So(<-this.output, should.Equal, 12)
 	this.So(<-this.output, should.BeNil) // channel closed
 }
 
+func (this *HandlerFixture) TestUnprojectedDeliveriesNotAcknowledged() {
+	this.transformer.err = errors.New("BOINK!")
+	this.input <- messaging.Delivery{Message: 1, Receipt: 11}
+
+	this.So(this.handler.Listen, should.PanicWith, "[ERROR] Unable to project 1 deliveries, which remain unacknowledged: BOINK!")
+	this.So(this.output, should.BeEmpty)
+}
+
+func (this *HandlerFixture) TestConfiguredHandlerProjectsIntoDocuments() {
+	store := NewFakeStorage()
+	document := &FakeDocument{}
+	handler := New(this.input, this.output, store, Documents(document), Clock(func() time.Time { return this.now }))
+
+	this.input <- messaging.Delivery{Message: 1, Receipt: 11}
+	go close(this.input)
+	handler.Listen()
+
+	this.So(document.now, should.Equal, this.now)
+	this.So(document.messages, should.Resemble, []interface{}{1})
+	this.So(store.writes[document.Path()], should.Equal, document)
+	this.So(<-this.output, should.Equal, 11)
+}
+
 /* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
 
 type FakeTransformer struct {
-	calls    int
-	now      time.Time
-	messages []interface{}
+	calls      int
+	now        time.Time
+	deliveries []messaging.Delivery
+	err        error
 }
 
-func (this *FakeTransformer) Transform(now time.Time, messages []interface{}) {
+func (this *FakeTransformer) Transform(now time.Time, deliveries []messaging.Delivery) error {
 	this.calls++
 	this.now = now
-	this.messages = append(this.messages, messages...)
+	this.deliveries = append(this.deliveries, deliveries...)
+	return this.err
 }
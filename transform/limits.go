@@ -0,0 +1,57 @@
+package transform
+
+import "time"
+
+// Backoff gives the time to wait following the numbered attempt (from 1) which failed.
+type Backoff func(attempt int) time.Duration
+
+// FixedBackoff waits the same time following every failed attempt.
+func FixedBackoff(duration time.Duration) Backoff {
+	return func(int) time.Duration { return duration }
+}
+
+// ExponentialBackoff waits the initial time following the first failed attempt,
+// doubling it following each of those thereafter up to the maximum.
+func ExponentialBackoff(initial, maximum time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		duration := initial
+		for i := 1; i < attempt && duration < maximum; i++ {
+			duration *= 2
+		}
+		if duration > maximum {
+			return maximum
+		}
+		return duration
+	}
+}
+
+// limits bound the loops which save each document, which are otherwise unbounded.
+type limits struct {
+	maxConflicts    int
+	conflictBackoff Backoff
+	maxReads        int
+	readBackoff     Backoff
+	sleep           func(time.Duration)
+}
+
+func (this limits) exceeded(limit, attempts int) bool {
+	return limit > 0 && attempts >= limit
+}
+func (this limits) wait(backoff Backoff, attempt int) {
+	if backoff == nil {
+		return
+	}
+
+	if duration := backoff(attempt); duration > 0 {
+		this.sleep(duration)
+	}
+}
+
+// Outcome describes the saving of a document following a batch of messages.
+type Outcome struct {
+	Path      string
+	Writes    int   // the attempts to write (or delete) the document
+	Conflicts int   // those writes which failed, most often by conflicting with another process
+	Reads     int   // the attempts to read the document afresh following a failed write
+	Err       error // why the document couldn't be saved, such as when the limits were exceeded
+}
@@ -0,0 +1,123 @@
+package transform
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestLimitsFixture(t *testing.T) {
+	gunit.Run(new(LimitsFixture), t)
+}
+
+type LimitsFixture struct {
+	*gunit.Fixture
+
+	store    *FakeStorage
+	document *FakeDocument
+	slept    []time.Duration
+	outcomes []Outcome
+}
+
+func (this *LimitsFixture) Setup() {
+	this.store = NewFakeStorage()
+	this.document = &FakeDocument{}
+}
+
+func (this *LimitsFixture) transformer(options ...Option) *simpleTransformer {
+	options = append(options, Documents(this.document), Observe(func(outcome Outcome) { this.outcomes = append(this.outcomes, outcome) }))
+	config := newConfiguration(options)
+	config.limits.sleep = func(duration time.Duration) { this.slept = append(this.slept, duration) }
+	return newSimpleTransformer(this.document, this.store, config)
+}
+
+func (this *LimitsFixture) TestSavedAfterConflictsWithinLimit() {
+	this.store.writeErrorCount = 2
+
+	err := this.transformer(MaxConflicts(3, ExponentialBackoff(time.Millisecond, time.Second))).Transform(time.Now(), deliver("a"))
+
+	this.So(err, should.BeNil)
+	this.So(this.store.writeCount, should.Equal, 3)
+	this.So(this.slept, should.Resemble, []time.Duration{time.Millisecond, time.Millisecond * 2})
+	this.So(this.outcomes, should.Resemble, []Outcome{{Path: "/0", Writes: 3, Conflicts: 2, Reads: 2}})
+}
+
+func (this *LimitsFixture) TestConflictsBeyondLimitGiveUp() {
+	this.store.writeErrorCount = 3
+
+	err := this.transformer(MaxConflicts(2, nil)).Transform(time.Now(), deliver("a"))
+
+	this.So(errors.Is(err, ErrTooManyConflicts), should.BeTrue)
+	this.So(this.store.writeCount, should.Equal, 2)
+	this.So(this.slept, should.BeEmpty)
+	this.So(this.outcomes, should.HaveLength, 1)
+	this.So(this.outcomes[0].Err, should.Equal, err)
+	this.So(this.outcomes[0].Conflicts, should.Equal, 2)
+}
+
+func (this *LimitsFixture) TestConflictsCountedForEachBatch() {
+	transformer := this.transformer(MaxConflicts(2, nil))
+
+	this.store.writeErrorCount = 1
+	err1 := transformer.Transform(time.Now(), deliver("a"))
+	this.store.writeCount = 0
+	err2 := transformer.Transform(time.Now(), deliver("a"))
+
+	this.So(err1, should.BeNil)
+	this.So(err2, should.BeNil)
+}
+
+func (this *LimitsFixture) TestReadsRetriedWithBackoffByDefault() {
+	this.store.writeErrorCount = 1
+	this.store.readErrorCount = 2
+
+	err := this.transformer().Transform(time.Now(), deliver("a"))
+
+	this.So(err, should.BeNil)
+	this.So(this.slept, should.Resemble, []time.Duration{time.Second * 5, time.Second * 5})
+	this.So(this.outcomes, should.Resemble, []Outcome{{Path: "/0", Writes: 2, Conflicts: 1, Reads: 3}})
+}
+
+func (this *LimitsFixture) TestReadsBeyondLimitGiveUp() {
+	this.store.writeErrorCount = 1
+	this.store.readErrorCount = 5
+
+	err := this.transformer(MaxReads(3, FixedBackoff(time.Millisecond))).Transform(time.Now(), deliver("a"))
+
+	this.So(err, should.NotBeNil)
+	this.So(err.Error(), should.Equal, "unable to read document [/0] after 3 attempt(s): BOINK!")
+	this.So(this.slept, should.Resemble, []time.Duration{time.Millisecond, time.Millisecond})
+	this.So(this.document.apply, should.Equal, 1) // not applied again
+}
+
+func (this *LimitsFixture) TestOutcomeOfUnsavedDocumentNotObserved() {
+	this.document.unmodified = true
+
+	_ = this.transformer().Transform(time.Now(), deliver("a"))
+
+	this.So(this.outcomes, should.BeEmpty)
+}
+
+func (this *LimitsFixture) TestFirstErrorOfDocumentsGivenBack() {
+	this.store.writeErrorCount = 100
+	other := &FakeDocument{index: 1}
+	transformer := newTransformer(this.store, newConfiguration([]Option{Documents(other, this.document), MaxConflicts(1, nil)}))
+
+	err := transformer.Transform(time.Now(), deliver("a"))
+
+	this.So(errors.Is(err, ErrTooManyConflicts), should.BeTrue)
+	this.So(err.Error(), should.ContainSubstring, "[/1]")
+}
+
+func (this *LimitsFixture) TestExponentialBackoffDoublesUpToMaximum() {
+	backoff := ExponentialBackoff(time.Second, time.Second*5)
+
+	this.So(backoff(1), should.Equal, time.Second)
+	this.So(backoff(2), should.Equal, time.Second*2)
+	this.So(backoff(3), should.Equal, time.Second*4)
+	this.So(backoff(4), should.Equal, time.Second*5)
+	this.So(backoff(1000), should.Equal, time.Second*5)
+}
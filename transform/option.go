@@ -0,0 +1,123 @@
+package transform
+
+import (
+	"time"
+
+	"github.com/smartystreets/projector"
+)
+
+type Option func(*configuration)
+
+type configuration struct {
+	now          func() time.Time
+	sleep        time.Duration
+	documents    []projector.Document
+	deduplicator deduplicator
+	lease        Lease
+	partition    Partition
+	resolvers    []assignedResolver
+	limits       limits
+	observe      func(Outcome)
+}
+
+type assignedResolver struct {
+	resolver  ConflictResolver
+	documents []projector.Document // or all documents, when none
+}
+
+func newConfiguration(options []Option) configuration {
+	config := configuration{now: utcNow, limits: limits{readBackoff: FixedBackoff(time.Second * 5), sleep: time.Sleep}}
+	for _, option := range options {
+		option(&config)
+	}
+	return config
+}
+
+// Documents are those into which messages are projected.
+func Documents(documents ...projector.Document) Option {
+	return func(this *configuration) { this.documents = append(this.documents, documents...) }
+}
+
+// Clock gives the time at which each batch of messages is projected (by default, the current UTC time).
+func Clock(now func() time.Time) Option {
+	return func(this *configuration) { this.now = now }
+}
+
+// Sleep pauses between batches of messages to allow larger batches to accumulate.
+func Sleep(duration time.Duration) Option {
+	return func(this *configuration) { this.sleep = duration }
+}
+
+// Deduplicate skips any message whose identity, as given by the function (or by Identifiable messages), was
+// among the last capacity (or DefaultDeduplicationCapacity) applied to a projector.Deduplicated document.
+func Deduplicate(identify func(message interface{}) string, capacity int) Option {
+	if identify == nil {
+		identify = identifiable
+	}
+	if capacity < 1 {
+		capacity = DefaultDeduplicationCapacity
+	}
+
+	return func(this *configuration) { this.deduplicator = deduplicator{identify: identify, capacity: capacity} }
+}
+
+// Exclusive projects each batch of messages only while the lease is held, waiting (and checking every
+// second) until it is. The lease must be acquired and renewed elsewhere (e.g. by lease.Lease.Listen).
+func Exclusive(lease Lease) Option {
+	return func(this *configuration) { this.lease = lease }
+}
+
+// Partitioned projects messages only into the documents assigned to this process by the partition
+// (e.g. a *partition.Membership), such that each of several processes projects its own share of them.
+func Partitioned(partition Partition) Option {
+	return func(this *configuration) { this.partition = partition }
+}
+
+// ResolveConflicts settles conflicts with other processes writing the documents given, or all documents when none
+// are given, using the resolver rather than by reapplying the messages (or merging projector.Mergeable documents).
+// Where several resolvers are given for the same document, the last of them is used.
+func ResolveConflicts(resolver ConflictResolver, documents ...projector.Document) Option {
+	return func(this *configuration) {
+		this.resolvers = append(this.resolvers, assignedResolver{resolver: resolver, documents: documents})
+	}
+}
+
+// MaxConflicts gives up saving a document, giving back ErrTooManyConflicts, once writing it has failed (most often
+// by conflicting with other processes) the limit number of times for the same batch of messages, waiting between
+// each according to the backoff, if any. By default there is no limit and no wait.
+func MaxConflicts(limit int, backoff Backoff) Option {
+	return func(this *configuration) { this.limits.maxConflicts, this.limits.conflictBackoff = limit, backoff }
+}
+
+// MaxReads gives up saving a document once reading it afresh, following a failed write, has failed the limit number
+// of times in succession, waiting between each according to the backoff. By default there is no limit and a wait
+// of five seconds.
+func MaxReads(limit int, backoff Backoff) Option {
+	return func(this *configuration) { this.limits.maxReads, this.limits.readBackoff = limit, backoff }
+}
+
+// Observe is given the outcome of saving each document which was written, or which couldn't be, such as to keep
+// metrics describing how many attempts were needed. The outcomes of documents with several attempts are also logged.
+func Observe(observer func(Outcome)) Option {
+	return func(this *configuration) { this.observe = observer }
+}
+
+func (this configuration) resolver(document projector.Document) ConflictResolver {
+	resolver := Reapply()
+	if _, ok := document.(projector.Mergeable); ok {
+		resolver = Merge()
+	}
+	for _, assigned := range this.resolvers {
+		if len(assigned.documents) == 0 {
+			resolver = assigned.resolver
+		}
+		for _, candidate := range assigned.documents {
+			if candidate == document {
+				resolver = assigned.resolver
+			}
+		}
+	}
+	return resolver
+}
+
+func utcNow() time.Time { return time.Now().UTC() }
@@ -1,83 +1,300 @@
 package transform
 
 import (
+	"fmt"
 	"log"
 	"sync"
 	"time"
 
+	"github.com/smartystreets/messaging/v2"
 	"github.com/smartystreets/projector"
 	"github.com/smartystreets/projector/persist"
 )
 
+// Transformer projects a batch of deliveries into documents, giving back an error when a document couldn't be
+// saved within the limits configured (see MaxConflicts and MaxReads), in which case the deliveries shouldn't
+// be acknowledged.
 type Transformer interface {
-	Transform(time.Time, []interface{})
+	Transform(time.Time, []messaging.Delivery) error
+}
+
+// Partition assigns each document, by its path, to one of several processes, such as a *partition.Membership.
+// An error means this process can't tell which documents it owns, so the deliveries aren't acknowledged.
+type Partition interface {
+	Owns(path string) (bool, error)
 }
 
 type multiTransformer struct {
 	transformers []*simpleTransformer
+	errs         []error
 	waiter       sync.WaitGroup
+	partition    Partition
+	sleep        func(time.Duration)
+}
+
+// NewTransformer projects batches of deliveries into the documents given by the options,
+// for callers which gather the batches themselves rather than receiving them from a channel.
+func NewTransformer(storage persist.ReadWriter, options ...Option) Transformer {
+	config := newConfiguration(options)
+	transformer := newTransformer(storage, config)
+	if config.lease != nil {
+		transformer = newExclusiveTransformer(config.lease, transformer, time.Second, time.Sleep)
+	}
+	return transformer
 }
 
-func newTransformer(store persist.ReadWriter, documents ...projector.Document) Transformer {
+func newTransformer(store persist.ReadWriter, config configuration) Transformer {
 	var transformers []*simpleTransformer
-	for _, document := range documents {
-		transformers = append(transformers, newSimpleTransformer(document, store))
+	for _, document := range config.documents {
+		transformers = append(transformers, newSimpleTransformer(document, store, config))
 	}
 
-	return &multiTransformer{transformers: transformers}
+	return &multiTransformer{
+		transformers: transformers,
+		errs:         make([]error, len(transformers)),
+		partition:    config.partition,
+		sleep:        config.limits.sleep,
+	}
 }
-func (this *multiTransformer) Transform(now time.Time, messages []interface{}) {
+
+// Transform gives back the first error, in the order of the documents, of those which couldn't be saved.
+func (this *multiTransformer) Transform(now time.Time, deliveries []messaging.Delivery) error {
+	this.standBy()
+
 	count := len(this.transformers)
 	this.waiter.Add(count)
 
 	for i := 0; i < count; i++ {
-		go this.transform(i, now, messages) // this for loop is safe to execute because it evaluates "i" before "go"
+		go this.transform(i, now, deliveries) // this for loop is safe to execute because it evaluates "i" before "go"
 	}
 
 	this.waiter.Wait()
+
+	for _, err := range this.errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// standBy waits while the partition, if any, can't tell which documents are assigned to this process,
+// rather than acknowledge deliveries for documents it may yet be assigned.
+func (this *multiTransformer) standBy() {
+	if this.partition == nil || len(this.transformers) == 0 {
+		return
+	}
+
+	path := this.transformers[0].document.Path()
+	for waited := false; ; waited = true {
+		if _, err := this.partition.Owns(path); err == nil {
+			if waited {
+				log.Println("[INFO] Documents are assigned to this process; projecting deliveries.")
+			}
+			return
+		} else if !waited {
+			log.Printf("[INFO] Standing by until documents are assigned to this process: %s\n", err)
+		}
+		this.sleep(time.Second)
+	}
 }
-func (this *multiTransformer) transform(index int, now time.Time, messages []interface{}) {
-	this.transformers[index].Transform(now, messages)
+func (this *multiTransformer) disown() {
+	for _, transformer := range this.transformers {
+		transformer.disowned = true
+	}
+}
+func (this *multiTransformer) transform(index int, now time.Time, deliveries []messaging.Delivery) {
+	this.errs[index] = this.transformers[index].Transform(now, deliveries)
 	this.waiter.Done()
 }
 
 /* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
 
 type simpleTransformer struct {
-	document projector.Document
-	storage  persist.ReadWriter
+	document     projector.Document
+	storage      persist.ReadWriter
+	deduplicator deduplicator
+	partition    Partition
+	disowned     bool
+	resolver     ConflictResolver
+	limits       limits
+	observe      func(Outcome)
+	outcome      Outcome
 }
 
-func newSimpleTransformer(document projector.Document, storage persist.ReadWriter) *simpleTransformer {
-	return &simpleTransformer{document: document, storage: storage}
+func newSimpleTransformer(document projector.Document, storage persist.ReadWriter, config configuration) *simpleTransformer {
+	return &simpleTransformer{
+		document:     document,
+		storage:      storage,
+		deduplicator: config.deduplicator,
+		partition:    config.partition,
+		resolver:     config.resolver(document),
+		limits:       config.limits,
+		observe:      config.observe,
+	}
 }
-func (this *simpleTransformer) Transform(now time.Time, messages []interface{}) {
+func (this *simpleTransformer) Transform(now time.Time, deliveries []messaging.Delivery) error {
 	this.document = this.document.Lapse(now)
-	for this.apply(messages) && !this.save() {
+	this.outcome = Outcome{Path: this.document.Path()}
+	if owned, err := this.owned(); !owned || err != nil {
+		return this.report(err)
+	}
+
+	lapsed := this.removed() // a removal requested by Lapse must survive the Reset which follows a conflict
+	for this.apply(deliveries) || lapsed {
+		if saved, err := this.save(lapsed); saved || err != nil {
+			return this.report(err)
+		}
 	}
+
+	return this.report(nil)
 }
-func (this *simpleTransformer) apply(messages []interface{}) (modified bool) {
-	for _, message := range messages {
-		if message != nil {
-			modified = this.document.Apply(message) || modified
+func (this *simpleTransformer) apply(deliveries []messaging.Delivery) (modified bool) {
+	for _, delivery := range deliveries {
+		if this.accept(delivery) {
+			modified = this.document.Apply(delivery.Message) || modified
 		}
 	}
 	return modified
 }
-func (this *simpleTransformer) save() bool {
-	if err := this.storage.Write(this.document); err == nil {
-		return true
+func (this *simpleTransformer) accept(delivery messaging.Delivery) bool {
+	if delivery.Message == nil {
+		return false
+	}
+
+	// deliveries without an identifier can't be checkpointed
+	if checkpointed, ok := this.document.(projector.Checkpointed); ok && delivery.MessageID > 0 {
+		if delivery.MessageID <= checkpointed.Checkpoint() {
+			return false
+		}
+		checkpointed.SetCheckpoint(delivery.MessageID)
 	}
 
+	return !this.deduplicator.duplicate(this.document, delivery.Message)
+}
+
+// save writes (or deletes) the document, reporting whether it did. Should that fail, the document is either
+// prepared to be written again by resolving the conflict or else read afresh, such that the messages are applied
+// again. Failures other than conflicts are always settled by reading it afresh, as the write may have succeeded.
+func (this *simpleTransformer) save(lapsed bool) (bool, error) {
 	for {
+		this.outcome.Writes++
+		err := this.persist(lapsed || this.removed())
+		if err == nil {
+			this.saved()
+			return true, nil
+		}
+
+		if this.outcome.Conflicts++; this.limits.exceeded(this.limits.maxConflicts, this.outcome.Conflicts) {
+			return false, fmt.Errorf("%w: unable to save document [%s] after %d failed write(s): %s",
+				ErrTooManyConflicts, this.document.Path(), this.outcome.Conflicts, err)
+		}
+		this.limits.wait(this.limits.conflictBackoff, this.outcome.Conflicts)
+
+		if err == persist.ErrConcurrentWrite {
+			if retry, err := this.resolver.Resolve(this.document, this.storage); err != nil {
+				return false, fmt.Errorf("unable to save document [%s]: %w", this.document.Path(), err)
+			} else if retry {
+				continue // the resolved document is written again as it is
+			}
+		}
+
+		return false, this.reload() // save didn't complete, messages need to be reapplied
+	}
+}
+func (this *simpleTransformer) reload() error {
+	for attempt := 1; ; attempt++ {
 		this.document.Reset()
+		this.outcome.Reads++
+
+		err := this.storage.Read(this.document)
+		if err == nil {
+			this.document.SetVersion(stored(this.document))
+			return nil
+		}
+
+		log.Printf("[WARN] Error reading document [%s] (attempt %d): %s", this.document.Path(), attempt, err)
+		if this.limits.exceeded(this.limits.maxReads, attempt) {
+			return fmt.Errorf("unable to read document [%s] after %d attempt(s): %w", this.document.Path(), attempt, err)
+		}
+		this.limits.wait(this.limits.readBackoff, attempt)
+	}
+}
+
+// stored gives the version of the document just read which, when it wasn't found, is persist.Absent
+// such that it's only created should no other process have since created it.
+func stored(document projector.Document) interface{} {
+	if version := document.Version(); version != nil {
+		return version
+	}
 
-		if err := this.storage.Read(this.document); err == nil {
-			return false // save didn't complete, messages need to be reapplied
-		} else {
-			log.Printf("[WARN] Error reading document [%s]: %s", this.document.Path(), err)
+	return persist.Absent
+}
+
+// owned reports whether the document is assigned to this process, if partitioned. A document assigned
+// again (or otherwise disowned) is first read afresh, as another process has since been writing it.
+func (this *simpleTransformer) owned() (bool, error) {
+	if owned, err := this.assigned(); err != nil || !owned {
+		this.disowned = true
+		return false, err
+	} else if this.disowned {
+		log.Printf("[INFO] Document [%s] assigned to this process; reading it afresh.", this.document.Path())
+		if err := this.reload(); err != nil {
+			return false, err
 		}
+		this.disowned = false
+	}
+
+	return true, nil
+}
+func (this *simpleTransformer) assigned() (bool, error) {
+	if this.partition == nil {
+		return true, nil
+	}
+
+	return this.partition.Owns(this.document.Path())
+}
+
+// report describes how the document was saved, when it took more than a single write or couldn't be done at all.
+func (this *simpleTransformer) report(err error) error {
+	this.outcome.Err = err
+	if err != nil {
+		log.Printf("[ERROR] Document [%s] not saved after %d write(s), %d failed, and %d read(s): %s",
+			this.outcome.Path, this.outcome.Writes, this.outcome.Conflicts, this.outcome.Reads, err)
+	} else if this.outcome.Conflicts > 0 {
+		log.Printf("[INFO] Document [%s] saved after %d write(s), %d failed, and %d read(s).",
+			this.outcome.Path, this.outcome.Writes, this.outcome.Conflicts, this.outcome.Reads)
+	}
+
+	if this.observe != nil && (this.outcome.Writes > 0 || this.outcome.Reads > 0) {
+		this.observe(this.outcome)
+	}
+	return err
+}
+func (this *simpleTransformer) persist(remove bool) error {
+	if !remove {
+		return this.storage.Write(this.document)
+	}
+
+	deleter, ok := this.storage.(persist.Deleter)
+	if !ok {
+		log.Printf("[WARN] Storage [%s] is unable to delete document [%s], writing it instead.", this.storage.Name(), this.document.Path())
+		return this.storage.Write(this.document)
+	}
 
-		time.Sleep(time.Second * 5)
+	if err := deleter.Delete(this.document); err != nil {
+		return err
 	}
+
+	this.document.Reset()
+	return nil
+}
+func (this *simpleTransformer) saved() {
+	if mergeable, ok := this.document.(projector.Mergeable); ok {
+		mergeable.Saved()
+	}
+}
+func (this *simpleTransformer) removed() bool {
+	removable, ok := this.document.(projector.Removable)
+	return ok && removable.Removed()
 }
@@ -1,6 +1,8 @@
 package transform
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
@@ -8,6 +10,7 @@ import (
 
 	"github.com/smartystreets/assertions/should"
 	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/messaging/v2"
 	"github.com/smartystreets/projector"
 	"github.com/smartystreets/projector/persist"
 )
@@ -36,11 +39,11 @@ func (this *TransformerFixture) Setup() {
 		this.documents = append(this.documents, &FakeDocument{index: i})
 		docs = append(docs, this.documents[i])
 	}
-	this.transformer = newTransformer(this.store, docs...)
+	this.transformer = newTransformer(this.store, newConfiguration([]Option{Documents(docs...)}))
 }
 
 func (this *TransformerFixture) TestAllDocumentsTransformedAndWritten() {
-	this.transformer.Transform(this.now, this.messages)
+	this.transformer.Transform(this.now, deliver(this.messages...))
 
 	var applyTimes []time.Time
 	for _, document := range this.documents {
@@ -60,29 +63,238 @@ func (this *TransformerFixture) TestAllDocumentsTransformedAndWritten() {
 func (this *TransformerFixture) TestFailedWriteRetried() {
 	document := &FakeDocument{}
 	this.documents = []*FakeDocument{document}
-	this.transformer = newTransformer(this.store, document)
+	this.transformer = newTransformer(this.store, newConfiguration([]Option{Documents(document)}))
 	this.store.writeErrorCount = 1 // failure on the first write and success thereafter
 
-	this.transformer.Transform(this.now, this.messages)
+	this.transformer.Transform(this.now, deliver(this.messages...))
 
 	this.So(document.reset, should.Equal, 1)
 	this.So(this.store.writeCount, should.Equal, 2)
 	this.So(this.store.writes[document.Path()], should.Equal, document)
 	this.So(this.store.reads[document.Path()], should.Equal, document)
 }
+func (this *TransformerFixture) TestDocumentNotFoundUponReadingAfreshCreatedOnlyIfStillAbsent() {
+	document := &FakeDocument{}
+	this.transformer = newTransformer(this.store, newConfiguration([]Option{Documents(document)}))
+	this.store.writeErrorCount = 1
+
+	this.transformer.Transform(this.now, deliver(this.messages...))
+
+	this.So(document.version == persist.Absent, should.BeTrue)
+}
+
+func (this *TransformerFixture) TestRemovedDocumentDeletedRatherThanWritten() {
+	document := &FakeDocument{removed: true}
+	this.transformer = newTransformer(this.store, newConfiguration([]Option{Documents(document)}))
+
+	this.transformer.Transform(this.now, deliver(this.messages...))
+
+	this.So(this.store.deletes[document.Path()], should.Equal, document)
+	this.So(this.store.writes, should.BeEmpty)
+	this.So(document.reset, should.Equal, 1) // afresh after deletion
+}
+
+func (this *TransformerFixture) TestDeletedDocumentWrittenAfterwards() {
+	document := &FakeDocument{removed: true}
+	this.transformer = newTransformer(this.store, newConfiguration([]Option{Documents(document)}))
+	this.transformer.Transform(this.now, deliver(this.messages...))
+
+	this.transformer.Transform(this.now, deliver(this.messages...))
+
+	this.So(this.store.deleteCount, should.Equal, 1)
+	this.So(this.store.writes[document.Path()], should.Equal, document)
+}
+
+func (this *TransformerFixture) TestDocumentRemovedByLapseDeletedWithoutMessages() {
+	document := &FakeDocument{removed: true}
+	this.transformer = newTransformer(this.store, newConfiguration([]Option{Documents(document)}))
+
+	this.transformer.Transform(this.now, nil)
+
+	this.So(document.apply, should.Equal, 0)
+	this.So(this.store.deletes[document.Path()], should.Equal, document)
+}
+
+func (this *TransformerFixture) TestFailedDeleteRetried() {
+	document := &FakeDocument{removed: true}
+	this.transformer = newTransformer(this.store, newConfiguration([]Option{Documents(document)}))
+	this.store.deleteErrorCount = 1
+
+	this.transformer.Transform(this.now, deliver(this.messages...))
+
+	this.So(this.store.deleteCount, should.Equal, 2)
+	this.So(this.store.reads[document.Path()], should.Equal, document)
+	this.So(this.store.writes, should.BeEmpty)
+	this.So(document.apply, should.Equal, len(this.messages)*2)
+}
+
+func (this *TransformerFixture) TestStandsByUntilAssignmentKnown() {
+	partition := &FakePartition{owned: map[string]bool{"/0": true}, err: errors.New("BOINK!")}
+	config := newConfiguration([]Option{Documents(this.documents[0]), Partitioned(partition)})
+	var slept []time.Duration
+	config.limits.sleep = func(duration time.Duration) {
+		if slept = append(slept, duration); len(slept) == 2 {
+			partition.err = nil
+		}
+	}
+	this.transformer = newTransformer(this.store, config)
+
+	err := this.transformer.Transform(this.now, deliver(this.messages...))
+
+	this.So(err, should.BeNil)
+	this.So(slept, should.Resemble, []time.Duration{time.Second, time.Second})
+	this.So(this.documents[0].apply, should.Equal, len(this.messages))
+}
+
+func (this *TransformerFixture) TestAssignmentLapsedDuringBatchLeavesDeliveriesUnacknowledged() {
+	partition := &FakePartition{owned: map[string]bool{"/0": true}, lapseAfter: 1, err: errors.New("BOINK!")}
+	document := this.documents[0]
+	this.transformer = newTransformer(this.store, newConfiguration([]Option{Documents(document), Partitioned(partition)}))
+
+	err := this.transformer.Transform(this.now, deliver(this.messages...))
+
+	this.So(err, should.Equal, partition.err)
+	this.So(document.apply, should.Equal, 0)
+	this.So(this.store.writes, should.BeEmpty)
+
+	partition.asked, partition.lapseAfter = 0, 0
+	partition.err = nil
+	_ = this.transformer.Transform(this.now, deliver(this.messages...))
+	this.So(this.store.reads["/0"], should.Equal, document) // another process may have written it meanwhile
+}
+
+func (this *TransformerFixture) TestCheckpointedDeliveriesSkipped() {
+	document := &CheckpointedDocument{}
+	document.SetCheckpoint(2)
+	this.transformer = newTransformer(this.store, newConfiguration([]Option{Documents(document)}))
+
+	this.transformer.Transform(this.now, []messaging.Delivery{
+		{MessageID: 1, Message: "a"},
+		{MessageID: 2, Message: "b"},
+		{MessageID: 3, Message: "c"},
+		{MessageID: 0, Message: "d"}, // unidentified deliveries are always applied
+		{MessageID: 4, Message: "e"},
+	})
+
+	this.So(document.messages, should.Resemble, []interface{}{"c", "d", "e"})
+	this.So(document.Checkpoint(), should.Equal, 4)
+	this.So(this.store.writes[document.Path()], should.Equal, document)
+}
+
+func (this *TransformerFixture) TestRedeliveredMessagesNotWritten() {
+	document := &CheckpointedDocument{}
+	document.SetCheckpoint(5)
+	this.transformer = newTransformer(this.store, newConfiguration([]Option{Documents(document)}))
+
+	this.transformer.Transform(this.now, []messaging.Delivery{{MessageID: 4, Message: "a"}, {MessageID: 5, Message: "b"}})
+
+	this.So(document.messages, should.BeEmpty)
+	this.So(this.store.writes, should.BeEmpty)
+}
+
+func (this *TransformerFixture) TestCheckpointRestoredAfterConflict() {
+	document := &CheckpointedDocument{}
+	this.transformer = newTransformer(this.store, newConfiguration([]Option{Documents(document)}))
+	this.store.writeErrorCount = 1
+	this.store.onRead = func(read projector.Document) { read.(*CheckpointedDocument).SetCheckpoint(2) } // written elsewhere
+
+	this.transformer.Transform(this.now, deliver("a", "b", "c"))
+
+	this.So(document.messages, should.Resemble, []interface{}{"a", "b", "c", "c"})
+	this.So(document.Checkpoint(), should.Equal, 3)
+	this.So(this.store.writeCount, should.Equal, 2)
+}
+
+func (this *TransformerFixture) TestCheckpointClearedByConflictWithDocumentWithoutCheckpoint() {
+	document := &CheckpointedDocument{}
+	this.transformer = newTransformer(this.store, newConfiguration([]Option{Documents(document)}))
+	this.store.writeErrorCount = 1
+	this.store.onRead = func(read projector.Document) { _ = json.Unmarshal([]byte(`{}`), read) } // no checkpoint yet
+
+	this.transformer.Transform(this.now, []messaging.Delivery{{MessageID: 1, Message: "a"}, {MessageID: 2, Message: "b"}})
+
+	this.So(document.messages, should.Resemble, []interface{}{"a", "b", "a", "b"})
+	this.So(document.Checkpoint(), should.Equal, 2)
+	this.So(this.store.writeCount, should.Equal, 2)
+}
+
+func (this *TransformerFixture) TestOnlyDocumentsOwnedByPartitionTransformed() {
+	partition := &FakePartition{owned: map[string]bool{"/1": true}}
+	this.transformer = newTransformer(this.store, newConfiguration([]Option{
+		Documents(this.documents[0], this.documents[1]), Partitioned(partition),
+	}))
+
+	this.transformer.Transform(this.now, deliver(this.messages...))
+
+	this.So(this.documents[0].apply, should.Equal, 0)
+	this.So(this.documents[1].apply, should.Equal, len(this.messages))
+	this.So(this.store.writes, should.HaveLength, 1)
+	this.So(this.store.writes["/1"], should.Equal, this.documents[1])
+}
+
+func (this *TransformerFixture) TestDocumentReassignedToPartitionReadAfresh() {
+	partition := &FakePartition{owned: map[string]bool{}}
+	document := this.documents[0]
+	this.transformer = newTransformer(this.store, newConfiguration([]Option{Documents(document), Partitioned(partition)}))
+	this.transformer.Transform(this.now, deliver(this.messages...))
+
+	partition.owned["/0"] = true
+	this.transformer.Transform(this.now, deliver(this.messages...))
+	this.transformer.Transform(this.now, deliver(this.messages...))
+
+	this.So(document.reset, should.Equal, 1)
+	this.So(this.store.reads["/0"], should.Equal, document)
+	this.So(document.apply, should.Equal, len(this.messages)*2)
+}
 
 /* ////////////////////////////////////////////////////////////////////////////////////////////////////////////////// */
 
+// FakePartition gives back its error, if any, once it has been asked the given number of times.
+type FakePartition struct {
+	mutex      sync.Mutex
+	owned      map[string]bool
+	err        error
+	asked      int
+	lapseAfter int
+}
+
+func (this *FakePartition) Owns(path string) (bool, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.asked++; this.asked > this.lapseAfter {
+		return this.owned[path], this.err
+	}
+	return this.owned[path], nil
+}
+
+func deliver(messages ...interface{}) (deliveries []messaging.Delivery) {
+	for i, message := range messages {
+		deliveries = append(deliveries, messaging.Delivery{MessageID: uint64(i + 1), Message: message})
+	}
+	return deliveries
+}
+
 type FakeStorage struct {
-	mutex           sync.Mutex
-	reads           map[string]projector.Document
-	writes          map[string]projector.Document
-	writeCount      int
-	writeErrorCount int
+	mutex            sync.Mutex
+	reads            map[string]projector.Document
+	writes           map[string]projector.Document
+	deletes          map[string]projector.Document
+	writeCount       int
+	writeErrorCount  int
+	deleteCount      int
+	deleteErrorCount int
+	readCount        int
+	readErrorCount   int
+	onRead           func(projector.Document)
 }
 
 func NewFakeStorage() *FakeStorage {
-	return &FakeStorage{reads: map[string]projector.Document{}, writes: map[string]projector.Document{}}
+	return &FakeStorage{
+		reads:   map[string]projector.Document{},
+		writes:  map[string]projector.Document{},
+		deletes: map[string]projector.Document{},
+	}
 }
 func (this *FakeStorage) Name() string                          { panic("nop") }
 func (this *FakeStorage) ReadPanic(document projector.Document) { panic("nop") }
@@ -91,6 +303,12 @@ func (this *FakeStorage) Read(document projector.Document) error {
 	defer this.mutex.Unlock()
 
 	this.reads[document.Path()] = document
+	if this.readCount++; this.readCount <= this.readErrorCount {
+		return errors.New("BOINK!")
+	}
+	if this.onRead != nil {
+		this.onRead(document)
+	}
 	return nil
 }
 func (this *FakeStorage) Write(document projector.Document) error {
@@ -106,25 +324,56 @@ func (this *FakeStorage) Write(document projector.Document) error {
 	}
 }
 
+func (this *FakeStorage) Delete(document projector.Document) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.deletes[document.Path()] = document
+
+	if this.deleteCount++; this.deleteCount >= this.deleteErrorCount+1 {
+		return nil
+	} else {
+		return persist.ErrConcurrentWrite
+	}
+}
+
 type FakeDocument struct {
-	index     int
-	apply     int
-	reset     int
-	applyTime time.Time
-	now       time.Time
-	messages  []interface{}
-	version   interface{}
+	index      int
+	apply      int
+	reset      int
+	applyTime  time.Time
+	now        time.Time
+	messages   []interface{}
+	version    interface{}
+	removed    bool
+	unmodified bool
 }
 
 func (this *FakeDocument) Apply(message interface{}) bool {
 	this.apply++
 	this.applyTime = utcNow()
 	this.messages = append(this.messages, message)
-	return true
+	return !this.unmodified
 }
 func (this *FakeDocument) Lapse(now time.Time) (next projector.Document) { this.now = now; return this }
 func (this *FakeDocument) Path() string                                  { return fmt.Sprintf("/%d", this.index) }
-func (this *FakeDocument) Reset()                                        { this.reset++ }
+func (this *FakeDocument) Reset()                                        { this.reset++; this.removed = false }
+func (this *FakeDocument) Removed() bool                                 { return this.removed }
 func (this *FakeDocument) SetVersion(value interface{})                  { this.version = value }
-func (this *FakeDocument) Version() interface{}                          { panic("nop") }
-func utcNow() time.Time                                                  { return time.Now().UTC() }
+func (this *FakeDocument) Version() interface{}                          { return this.version }
+
+type CheckpointedDocument struct {
+	projector.CheckpointInfo
+
+	messages []interface{}
+}
+
+func (this *CheckpointedDocument) Apply(message interface{}) bool {
+	this.messages = append(this.messages, message)
+	return true
+}
+func (this *CheckpointedDocument) Lapse(now time.Time) (next projector.Document) { return this }
+func (this *CheckpointedDocument) Path() string                                  { return "/checkpointed" }
+func (this *CheckpointedDocument) Reset()                                        { this.CheckpointInfo.Reset() }
+func (this *CheckpointedDocument) SetVersion(value interface{})                  {}
+func (this *CheckpointedDocument) Version() interface{}                          { return nil }